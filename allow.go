@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// shellRules are processes --allow-only always keeps, so terminals stay
+// usable. What they start is still checked.
+var shellRules = mustParseMatchRules(
+	"sh", "bash", "zsh", "fish", "dash", "ksh", "tcsh", "csh", "nu", "xonsh",
+	"alacritty", "kitty", "wezterm", "wezterm-gui", "foot", "ghostty",
+	"gnome-terminal", "gnome-terminal-server", "konsole", "xterm", "urxvt",
+	"st", "terminator", "tilix", "xfce4-terminal", "Terminal", "iTerm2",
+	"tmux", "screen", "zellij", "sshd", "mosh-server",
+)
+
+// sessionRules are desktop and session processes --allow-only keeps.
+// Apps launched from them are still checked.
+var sessionRules = mustParseMatchRules(
+	"systemd", "init", "launchd", "login", "dbus-daemon", "dbus-broker",
+	"glob:gdm*", "glob:gnome-session*", "gnome-shell", "glob:gsd-*",
+	"plasmashell", "glob:kwin*", "ksmserver", "glob:startplasma*", "glob:kded*",
+	"xfce4-session", "xfwm4", "xfce4-panel", "Xorg", "Xwayland",
+	"sway", "Hyprland", "i3", "waybar",
+	"pipewire", "pipewire-pulse", "wireplumber", "pulseaudio",
+	"glob:xdg-*", "glob:at-spi*", "glob:ibus*", "glob:fcitx*", "glob:gvfs*",
+	"gnome-keyring-daemon", "glob:polkit*", "ssh-agent", "gpg-agent",
+	"loginwindow", "WindowServer", "Dock", "Finder", "SystemUIServer",
+	"ControlCenter", "NotificationCenter", "Spotlight",
+	"lockin",
+)
+
+func mustParseMatchRules(entries ...string) []matchRule {
+	rules, err := parseMatchRules(entries)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// allowList picks the processes --allow-only closes: apps and terminal
+// programs owned by the user that aren't allowed, safe, or started from
+// an allowed app or by lockin itself.
+type allowList struct {
+	rules    []matchRule
+	uid      int
+	appNames map[string]bool // names of installed desktop apps
+}
+
+func newAllowList(rules []matchRule) *allowList {
+	a := &allowList{rules: rules, uid: targetUID(), appNames: map[string]bool{}}
+	for _, app := range desktopApps(true) {
+		for _, name := range app.names() {
+			a.appNames[name] = true
+		}
+	}
+	return a
+}
+
+// targetUID is the user whose apps are checked; under sudo that is the
+// invoking user rather than root.
+func targetUID() int {
+	if os.Getuid() == 0 {
+		if uid, err := strconv.Atoi(os.Getenv("SUDO_UID")); err == nil {
+			return uid
+		}
+	}
+	return os.Getuid()
+}
+
+// targets returns the processes in procs that aren't allowed. self holds
+// lockin and its ancestors.
+func (a *allowList) targets(procs []process, self map[int]bool) []process {
+	byPID := make(map[int]process, len(procs))
+	for _, p := range procs {
+		byPID[p.pid] = p
+	}
+
+	var out []process
+	for _, p := range procs {
+		if self[p.pid] || p.uid != a.uid || len(p.cmdline) == 0 || !a.isApp(p) {
+			continue
+		}
+		if a.allowed(p) || matchesRules(shellRules, p) || matchesRules(sessionRules, p) || a.inherits(p, byPID) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// isApp reports whether p is an interactive program: it has a terminal,
+// is an installed desktop app, or runs from an app bundle.
+func (a *allowList) isApp(p process) bool {
+	if p.tty {
+		return true
+	}
+	for _, name := range p.names() {
+		if a.appNames[name] {
+			return true
+		}
+	}
+	return filepath.Ext(filepath.Dir(filepath.Dir(p.exe))) == ".app"
+}
+
+func (a *allowList) allowed(p process) bool {
+	return matchesRules(a.rules, p)
+}
+
+// inherits reports whether p was started, directly or not, by an allowed
+// app or by lockin, such as a hook. A shell doesn't count: firefox & typed
+// into a terminal is checked like any other app.
+func (a *allowList) inherits(p process, byPID map[int]process) bool {
+	seen := map[int]bool{p.pid: true}
+	for pid := p.ppid; pid > 1 && !seen[pid]; {
+		seen[pid] = true
+		parent, ok := byPID[pid]
+		if !ok {
+			return false
+		}
+		if pid == os.Getpid() || a.allowed(parent) {
+			return true
+		}
+		pid = parent.ppid
+	}
+	return false
+}
+
+func matchesRules(rules []matchRule, p process) bool {
+	for _, rule := range rules {
+		if rule.match(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// applicationDirs holds desktop entries on Linux and app bundles on macOS.
+func applicationDirs() []string {
+	dirs := []string{
+		"/usr/share/applications",
+		"/usr/local/share/applications",
+		"/var/lib/flatpak/exports/share/applications",
+		"/var/lib/snapd/desktop/applications",
+		"/Applications",
+		"/System/Applications",
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, ".local/share/applications"),
+			filepath.Join(home, ".local/share/flatpak/exports/share/applications"),
+			filepath.Join(home, "Applications"))
+	}
+	return dirs
+}
+
+// installedApps describes every executable on $PATH, desktop entry and
+// app bundle as a process, so blocklist rules can be checked against
+// what could run as well as what is running.
+func installedApps() []process {
+	var apps []process
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			if seen[path] || e.IsDir() {
+				continue
+			}
+			seen[path] = true
+			apps = append(apps, process{comm: e.Name(), exe: path, cmdline: []string{path}})
+		}
+	}
+	return append(apps, desktopApps(false)...)
+}
+
+// desktopApps describes every desktop entry and app bundle as a process.
+// guiOnly leaves out terminal programs and hidden helper entries.
+func desktopApps(guiOnly bool) []process {
+	var apps []process
+	for _, dir := range applicationDirs() {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			switch {
+			case strings.HasSuffix(e.Name(), ".desktop"):
+				entry, gui := desktopEntry(path)
+				if gui || !guiOnly {
+					apps = append(apps, entry...)
+				}
+			case strings.HasSuffix(e.Name(), ".app"):
+				name := strings.TrimSuffix(e.Name(), ".app")
+				apps = append(apps, process{comm: name, exe: filepath.Join(path, "Contents", "MacOS", name)})
+			}
+		}
+	}
+	return apps
+}
+
+// desktopEntry reads the Name and Exec keys of a .desktop file, and
+// whether it launches a visible graphical app.
+func desktopEntry(path string) ([]process, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	apps := []process{{comm: strings.TrimSuffix(filepath.Base(path), ".desktop")}}
+	gui := true
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && line != "[Desktop Entry]" {
+			break // actions and other groups
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Terminal", "NoDisplay":
+			if val == "true" {
+				gui = false
+			}
+		case "Name":
+			apps = append(apps, process{comm: val})
+		case "Exec":
+			fields := strings.Fields(val)
+			// Skip an env wrapper: Exec=env FOO=1 app
+			if len(fields) > 0 && filepath.Base(fields[0]) == "env" {
+				fields = fields[1:]
+				for len(fields) > 0 && strings.Contains(fields[0], "=") {
+					fields = fields[1:]
+				}
+			}
+			if len(fields) > 0 {
+				p := process{comm: filepath.Base(fields[0]), cmdline: fields}
+				if filepath.IsAbs(fields[0]) {
+					p.exe = fields[0]
+				}
+				apps = append(apps, p)
+			}
+		}
+	}
+	return apps, gui
+}
+
+// unmatchedRules returns the rules that match neither a running process
+// nor anything installed. cmdline: rules can't be checked ahead of time
+// and are never reported.
+func unmatchedRules(rules []matchRule, running []process) []matchRule {
+	var unmatched []matchRule
+	var installed []process
+	for _, rule := range rules {
+		if rule.kind == "cmdline" || matchesAny(rule, running) {
+			continue
+		}
+		if rule.kind == "exe" {
+			if matches, _ := filepath.Glob(rule.pattern); len(matches) > 0 {
+				continue
+			}
+			if info, err := os.Stat(rule.pattern); err == nil && info.IsDir() {
+				continue
+			}
+		}
+		if installed == nil {
+			installed = installedApps()
+		}
+		if !matchesAny(rule, installed) {
+			unmatched = append(unmatched, rule)
+		}
+	}
+	return unmatched
+}
+
+func matchesAny(rule matchRule, procs []process) bool {
+	for _, p := range procs {
+		if rule.match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// blocklistWarnings flags rules that look like typos.
+func blocklistWarnings(rules []matchRule) []string {
+	if len(rules) == 0 {
+		return nil
+	}
+	running, _ := listProcesses()
+	var warnings []string
+	for _, rule := range unmatchedRules(rules, running) {
+		warnings = append(warnings, fmt.Sprintf("%s doesn't match any installed app", rule.raw))
+	}
+	return warnings
+}
+
+// runBlockDryRun prints what each rule would block right now without
+// signaling anything.
+func runBlockDryRun(cfg config) {
+	if len(cfg.blockRules) == 0 && len(cfg.breakBlockRules) == 0 && len(cfg.allowRules) == 0 && len(cfg.blockSites) == 0 {
+		fmt.Fprintln(os.Stderr, "error: --block-dry-run needs --block, --break-block, --allow-only or --block-sites")
+		os.Exit(1)
+	}
+
+	procs, err := listProcesses()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	self := ancestors(procs, os.Getpid())
+	unmatched := map[string]bool{}
+	for _, rule := range unmatchedRules(slices.Concat(cfg.blockRules, cfg.breakBlockRules, cfg.allowRules), procs) {
+		unmatched[rule.raw] = true
+	}
+
+	verb := "close"
+	if cfg.blockMode == blockFreeze {
+		verb = "freeze"
+	}
+	printRules := func(prefix string, rules []matchRule) {
+		for _, rule := range rules {
+			var matches []string
+			for _, p := range procs {
+				if !self[p.pid] && rule.match(p) {
+					matches = append(matches, fmt.Sprintf("%s (pid %d)", p.displayName(), p.pid))
+				}
+			}
+			name := prefix + rule.raw
+			switch {
+			case len(matches) > 0 && rule.budget > 0:
+				fmt.Printf("%s: would allow %s for %s, then %s it\n", name, strings.Join(matches, ", "), rule.allowance(), verb)
+			case len(matches) > 0:
+				fmt.Printf("%s: would %s %s\n", name, verb, strings.Join(matches, ", "))
+			case unmatched[rule.raw]:
+				fmt.Printf("%s: not running, and doesn't match any installed app\n", name)
+			default:
+				fmt.Printf("%s: not running\n", name)
+			}
+		}
+	}
+	printRules("", cfg.blockRules)
+	// Break rules are checked as if a break were on now
+	printRules("on breaks, ", cfg.breakBlockRules)
+
+	if len(cfg.allowRules) > 0 {
+		for _, rule := range cfg.allowRules {
+			if unmatched[rule.raw] {
+				fmt.Printf("allow %s: doesn't match any installed app\n", rule.raw)
+			}
+		}
+		var targets []string
+		for _, p := range newAllowList(cfg.allowRules).targets(procs, self) {
+			targets = append(targets, fmt.Sprintf("%s (pid %d)", p.displayName(), p.pid))
+		}
+		if len(targets) > 0 {
+			fmt.Printf("not allowed: would %s %s\n", verb, strings.Join(targets, ", "))
+		} else {
+			fmt.Println("not allowed: nothing running")
+		}
+	}
+
+	hosts := cfg.hostsFile
+	if hosts == "" {
+		hosts = defaultHostsFile
+	}
+	for _, site := range cfg.blockSites {
+		fmt.Printf("%s: would be blocked in %s\n", site, hosts)
+	}
+}
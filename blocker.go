@@ -1,35 +1,659 @@
 package main
 
 import (
-	"os/exec"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
+)
+
+// blockHit records one process the blocker signaled.
+type blockHit struct {
+	app  string // blocklist rule that matched
+	pid  int
+	name string
+	at   time.Time
+	err  error // non-nil if the signal failed, e.g. another user's process
+
+	children int  // other processes in its tree signaled along with it
+	forced   bool // SIGKILLed after ignoring SIGTERM for the grace period
+}
+
+// closing is a process tree on its way out: warned about until termAt,
+// sent SIGTERM then, and SIGKILL at killAt if any of it is still around.
+type closing struct {
+	app    string
+	root   process
+	termAt time.Time
+	killAt time.Time // zero until SIGTERM is sent
+	tree   []int     // pids signaled with SIGTERM
+}
+
+const maxBlockHits = 200
+
+// sweepInterval is how often the blocker rescans the process table.
+const sweepInterval = 5 * time.Second
+
+// escalateInterval is how often warned and terminated trees are checked.
+const escalateInterval = 250 * time.Millisecond
+
+// Defaults for the warning countdown before an app is closed, and the
+// time it gets to exit after SIGTERM before it is killed outright.
+const (
+	defaultBlockWarning = 3 * time.Second
+	defaultBlockGrace   = 5 * time.Second
+)
 
-	tea "github.com/charmbracelet/bubbletea"
+// Block modes: kill closes matching processes, freeze stops them with
+// SIGSTOP and continues them when the blocker pauses or shuts down.
+const (
+	blockKill   = "kill"
+	blockFreeze = "freeze"
 )
 
-func startBlocker(apps []string, paused *atomic.Bool, stop chan struct{}) tea.Cmd {
-	return func() tea.Msg {
-		killApps(apps)
+// procBlocker terminates or freezes blocklisted processes by scanning the
+// process table itself rather than forking pkill for every app.
+type procBlocker struct {
+	workRules  []matchRule
+	breakRules []matchRule // enforced during pomodoro breaks instead
+	allow      *allowList  // nil unless --allow-only is set; work only
+	mode       string
+	warning    time.Duration // countdown shown before closing an app
+	grace      time.Duration // between SIGTERM and SIGKILL
+	paused     atomic.Bool
+	stop       chan struct{}
+	resweep    chan struct{} // asks run for a sweep after the rules change or lost exec events
+
+	mu      sync.Mutex
+	rules   []matchRule // workRules or breakRules
+	onBreak bool
+	hits    []blockHit     // most recent last, capped at maxBlockHits
+	counts  map[string]int // times each rule found its app running
+	scanErr error
+	frozen  map[int]string // pids stopped in freeze mode, and the app they count under
+	self    map[int]bool   // lockin and its ancestors as of the last sweep
+	closed  bool
+	closing map[int]*closing // trees being closed, by root pid
+	doomed  map[int]int      // pids in those trees, to their root
+
+	started    time.Time
+	allowances map[string]*allowance // by rule, for rules with a budget
+
+	// The process table and signals, replaced in tests
+	list func() ([]process, error)
+	kill func(pid int, sig syscall.Signal) error
+}
+
+// allowance tracks how much of a rule's budget its app has used in the
+// current window.
+type allowance struct {
+	window int // windows since the blocker started
+	used   time.Duration
+	seen   time.Time // when the app was last seen running, zero if it wasn't
+}
+
+func newProcBlocker(rules, breakRules []matchRule, allow *allowList, mode string) *procBlocker {
+	if mode == "" {
+		mode = blockKill
+	}
+	return &procBlocker{
+		workRules:  rules,
+		breakRules: breakRules,
+		rules:      rules,
+		allow:      allow,
+		mode:       mode,
+		stop:       make(chan struct{}),
+		resweep:    make(chan struct{}, 1),
+		counts:     map[string]int{},
+		frozen:     map[int]string{},
+		closing:    map[int]*closing{},
+		doomed:     map[int]int{},
+		started:    time.Now(),
+		allowances: map[string]*allowance{},
+		list:       listProcesses,
+		kill:       syscall.Kill,
+	}
+}
+
+// verb describes what the blocker does to a matching process.
+func (b *procBlocker) verb() string {
+	if b.mode == blockFreeze {
+		return "froze"
+	}
+	return "closed"
+}
+
+// setPaused idles the blocker. Pausing in freeze mode continues
+// everything frozen so far; the next sweep after resuming stops it again.
+func (b *procBlocker) setPaused(paused bool) {
+	b.paused.Store(paused)
+	if paused {
+		b.thaw()
+		// Time spent paused isn't charged to allowances
+		b.mu.Lock()
+		for _, a := range b.allowances {
+			a.seen = time.Time{}
+		}
+		b.spareLocked()
+		b.mu.Unlock()
+	}
+}
+
+// SetBreak swaps the work blocklist for the break one, or back. Nothing
+// closed under either list is restarted, and frozen apps are continued.
+func (b *procBlocker) SetBreak(onBreak bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.onBreak == onBreak {
+		return
+	}
+	b.onBreak = onBreak
+	b.rules = b.workRules
+	if onBreak {
+		b.rules = b.breakRules
+	}
+	b.thawLocked("")
+	b.spareLocked()
+	b.askSweep()
+}
+
+// askSweep asks run for a sweep soon, if it hasn't been asked already.
+func (b *procBlocker) askSweep() {
+	select {
+	case b.resweep <- struct{}{}:
+	default:
+	}
+}
+
+// active returns the rules in force and the allowlist, if it applies now.
+func (b *procBlocker) active() ([]matchRule, *allowList) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.onBreak {
+		return b.rules, nil
+	}
+	return b.rules, b.allow
+}
+
+func (b *procBlocker) Name() string { return b.mode }
+
+// Start sweeps once, then blocks new processes as they exec where the
+// platform reports that, and polls every 5 seconds regardless.
+func (b *procBlocker) Start() error {
+	b.sweep()
+	if _, err := b.report(); err != nil {
+		return err
+	}
+	go b.run()
+	return nil
+}
+
+func (b *procBlocker) run() {
+	execs := make(chan process, 64)
+	if stopWatch, err := watchExecs(execs, b.askSweep); err == nil {
+		defer stopWatch()
+	}
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	escalate := time.NewTicker(escalateInterval)
+	defer escalate.Stop()
+
+	// An allowlist needs the whole process tree, so exec events
+	// trigger a sweep, batched since they often come in bursts.
+	var allowSweep <-chan time.Time
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case p := <-execs:
+			_, allow := b.active()
+			switch {
+			case b.paused.Load():
+			case allow != nil:
+				if allowSweep == nil {
+					allowSweep = time.After(100 * time.Millisecond)
+				}
+			default:
+				b.check(p)
+			}
+		case <-allowSweep:
+			allowSweep = nil
+			if !b.paused.Load() {
+				b.sweep()
+			}
+		case <-ticker.C:
+			if !b.paused.Load() {
+				b.sweep()
+			}
+		case <-b.resweep:
+			if !b.paused.Load() {
+				b.sweep()
+			}
+		case <-escalate.C:
+			b.escalate(time.Now())
+		}
+	}
+}
+
+func (b *procBlocker) Pause()  { b.setPaused(true) }
+func (b *procBlocker) Resume() { b.setPaused(false) }
+func (b *procBlocker) Stop()   { b.close() }
+
+// Report lists each rule with its allowance and sightings, the newest
+// process signaled, and which processes were closed or frozen.
+func (b *procBlocker) Report() blockerReport {
+	hits, err := b.report()
+	attempts := b.attempts()
+	left := b.allowancesLeft()
+	r := blockerReport{attempts: attempts, err: err}
+	rules, allow := b.active()
+
+	for _, rule := range rules {
+		label := "🔒 " + rule.raw
+		if l, ok := left[rule.raw]; ok {
+			label += " " + formatDuration(l) + " left"
+		}
+		if n := attempts[rule.raw]; n > 0 {
+			label += fmt.Sprintf(" ×%d", n)
+		}
+		r.labels = append(r.labels, label)
+	}
+	var ruleApps []string
+	for _, rule := range append(b.workRules, b.breakRules...) {
+		if !slices.Contains(ruleApps, rule.raw) {
+			ruleApps = append(ruleApps, rule.raw)
+		}
+	}
+	// Apps closed for not being on the allowlist count under their own names
+	var others []string
+	for app := range attempts {
+		if !slices.Contains(ruleApps, app) {
+			others = append(others, app)
+		}
+	}
+	sort.Strings(others)
+	if allow != nil {
+		var allowed []string
+		for _, rule := range b.allow.rules {
+			allowed = append(allowed, rule.raw)
+		}
+		label := "✅ only " + strings.Join(allowed, ", ")
+		n := 0
+		for _, app := range others {
+			n += attempts[app]
+		}
+		if n > 0 {
+			label += fmt.Sprintf(" ×%d", n)
+		}
+		r.labels = append(r.labels, label)
+	}
+
+	if len(hits) > 0 {
+		last := hits[len(hits)-1]
+		r.noticeAt = last.at
+		switch {
+		case last.err != nil:
+			r.notice = fmt.Sprintf("could not block %s (pid %d): %v", last.name, last.pid, last.err)
+		case last.forced:
+			r.notice = fmt.Sprintf("force-killed %s (pid %d) after %s", last.name, last.pid, formatDuration(b.grace))
+		case last.children == 1:
+			r.notice = fmt.Sprintf("%s %s (pid %d and 1 child)", b.verb(), last.name, last.pid)
+		case last.children > 1:
+			r.notice = fmt.Sprintf("%s %s (pid %d and %d children)", b.verb(), last.name, last.pid, last.children)
+		default:
+			r.notice = fmt.Sprintf("%s %s (pid %d)", b.verb(), last.name, last.pid)
+		}
+	}
+	r.warnings = b.warnings(time.Now())
+
+	pids := map[string][]string{}
+	for _, hit := range hits {
+		if hit.err == nil && !hit.forced {
+			pids[hit.app] = append(pids[hit.app], strconv.Itoa(hit.pid))
+		}
+	}
+	for _, app := range append(ruleApps, others...) {
+		n := attempts[app]
+		if n == 0 {
+			continue
+		}
+		line := fmt.Sprintf("%s %s once", b.verb(), app)
+		if n > 1 {
+			line = fmt.Sprintf("%s %s %d times", b.verb(), app, n)
+		}
+		if len(pids[app]) > 0 {
+			line += fmt.Sprintf(" (pid %s)", strings.Join(pids[app], ", "))
+		}
+		r.summary = append(r.summary, line)
+	}
+	return r
+}
+
+// close stops the blocker and continues any frozen processes. It is safe
+// to call more than once.
+func (b *procBlocker) close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	select {
+	case <-b.stop:
+	default:
+		close(b.stop)
+	}
+	b.thaw()
+}
 
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
+// thaw sends SIGCONT to every process the blocker froze.
+func (b *procBlocker) thaw() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.thawLocked("")
+}
 
-		for {
-			select {
-			case <-stop:
-				return blockerStoppedMsg{}
-			case <-ticker.C:
-				if !paused.Load() {
-					killApps(apps)
+// thawLocked continues the processes frozen for app, or all of them if
+// app is empty.
+func (b *procBlocker) thawLocked(app string) {
+	for pid, frozenFor := range b.frozen {
+		if app == "" || frozenFor == app {
+			_ = b.kill(pid, syscall.SIGCONT)
+			delete(b.frozen, pid)
+		}
+	}
+}
+
+// sweep signals every running process matching a blocklist rule, other
+// than lockin itself and the processes that started it.
+func (b *procBlocker) sweep() {
+	procs, err := b.list()
+	b.mu.Lock()
+	b.scanErr = err
+	b.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	self := ancestors(procs, os.Getpid())
+	b.mu.Lock()
+	b.self = self
+	b.mu.Unlock()
+	matched := map[int]bool{}
+	running := map[string]bool{}
+	for _, p := range procs {
+		if rule, ok := b.check(p); ok {
+			matched[p.pid] = true
+			running[rule.raw] = true
+		}
+	}
+	rules, allow := b.active()
+	if allow != nil {
+		for _, p := range allow.targets(procs, self) {
+			if !matched[p.pid] {
+				b.block(p.displayName(), p)
+			}
+		}
+	}
+
+	// Stop charging allowances for apps that are no longer running
+	b.mu.Lock()
+	for _, rule := range rules {
+		if a := b.allowances[rule.raw]; a != nil && !running[rule.raw] {
+			a.seen = time.Time{}
+		}
+	}
+	b.mu.Unlock()
+}
+
+// check blocks p if it matches a rule, unless the rule's allowance has
+// time left. It returns the rule that matched, if any.
+func (b *procBlocker) check(p process) (matchRule, bool) {
+	b.mu.Lock()
+	self := b.self[p.pid] || p.pid == os.Getpid()
+	rules := b.rules
+	b.mu.Unlock()
+	if self {
+		return matchRule{}, false
+	}
+	for _, rule := range rules {
+		if !rule.match(p) {
+			continue
+		}
+		if rule.budget == 0 || !b.spend(rule, time.Now()) {
+			b.block(rule.raw, p)
+		}
+		return rule, true
+	}
+	return matchRule{}, false
+}
+
+// spend charges the time since rule's app was last seen running to its
+// allowance and reports whether any is left.
+func (b *procBlocker) spend(rule matchRule, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	a := b.allowanceLocked(rule, now)
+	// A long gap means a pause or suspend, not time the app was used
+	if d := now.Sub(a.seen); !a.seen.IsZero() && d > 0 && d <= 2*sweepInterval {
+		a.used += d
+	}
+	a.seen = now
+	return a.used < rule.budget
+}
+
+// allowanceLocked returns rule's allowance, starting it afresh when a new
+// window begins. Apps frozen for running out are continued then.
+func (b *procBlocker) allowanceLocked(rule matchRule, now time.Time) *allowance {
+	a := b.allowances[rule.raw]
+	if a == nil {
+		a = &allowance{}
+		b.allowances[rule.raw] = a
+	}
+	if rule.window > 0 {
+		if w := int(now.Sub(b.started) / rule.window); w != a.window {
+			*a = allowance{window: w}
+			b.thawLocked(rule.raw)
+		}
+	}
+	return a
+}
+
+// allowancesLeft returns the unused allowance of each rule with a budget.
+func (b *procBlocker) allowancesLeft() map[string]time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	left := map[string]time.Duration{}
+	for _, rule := range b.rules {
+		if rule.budget == 0 {
+			continue
+		}
+		a := b.allowanceLocked(rule, now)
+		used := a.used
+		if d := now.Sub(a.seen); !a.seen.IsZero() && d <= 2*sweepInterval {
+			used += d // still running since the last sweep
+		}
+		left[rule.raw] = max(rule.budget-used, 0)
+	}
+	return left
+}
+
+// block closes or freezes p and the processes it started, on behalf of
+// app, the rule or allowlisted name it's counted under. A process in a
+// tree already being closed is left to that.
+func (b *procBlocker) block(app string, p process) {
+	if b.mode != blockFreeze {
+		b.mu.Lock()
+		if root, ok := b.doomed[p.ppid]; ok {
+			b.doomed[p.pid] = root
+		}
+		_, doomed := b.doomed[p.pid]
+		if !doomed && !b.closed {
+			b.closing[p.pid] = &closing{app: app, root: p, termAt: time.Now().Add(b.warning)}
+			b.doomed[p.pid] = p.pid
+		}
+		b.mu.Unlock()
+		if !doomed && b.warning <= 0 {
+			b.escalate(time.Now())
+		}
+		return
+	}
+
+	// Hold the lock while stopping so close and thaw can't miss this pid
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.frozen[p.pid]; ok || b.closed || b.paused.Load() {
+		return
+	}
+	err := b.kill(p.pid, syscall.SIGSTOP)
+	hit := blockHit{app: app, pid: p.pid, name: p.displayName(), at: time.Now(), err: err}
+	if err == nil {
+		b.frozen[p.pid] = app
+		// Stop the rest of the tree too, so helpers can't restart it
+		if procs, err := b.list(); err == nil {
+			for _, pid := range descendants(procs, p.pid, b.self)[1:] {
+				if _, ok := b.frozen[pid]; !ok && b.kill(pid, syscall.SIGSTOP) == nil {
+					b.frozen[pid] = app
+					hit.children++
+				}
+			}
+		}
+	}
+	b.recordLocked(hit)
+}
+
+// escalate moves trees being closed along: SIGTERM once their warning
+// runs out, then SIGKILL for whatever outlives the grace period.
+func (b *procBlocker) escalate(now time.Time) {
+	b.mu.Lock()
+	due := false
+	for _, c := range b.closing {
+		due = due || !c.killAt.IsZero() || !now.Before(c.termAt)
+	}
+	b.mu.Unlock()
+	if !due {
+		return
+	}
+	procs, err := b.list()
+	if err != nil {
+		return
+	}
+	running := make(map[int]bool, len(procs))
+	for _, p := range procs {
+		running[p.pid] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for root, c := range b.closing {
+		switch {
+		case c.killAt.IsZero() && !running[root]:
+			// Quit on its own during the warning
+			b.forgetLocked(root)
+		case c.killAt.IsZero() && !now.Before(c.termAt):
+			c.tree = descendants(procs, root, b.self)
+			var err error
+			for _, pid := range c.tree {
+				if other, ok := b.closing[pid]; ok && pid != root && other.killAt.IsZero() {
+					delete(b.closing, pid) // part of this tree, so closed with it
+				}
+				if e := b.kill(pid, syscall.SIGTERM); pid == root {
+					err = e
 				}
+				b.doomed[pid] = root
+			}
+			c.killAt = now.Add(b.grace)
+			b.recordLocked(blockHit{app: c.app, pid: root, name: c.root.displayName(), at: now, err: err, children: len(c.tree) - 1})
+			if err != nil {
+				b.forgetLocked(root)
+			}
+		case !c.killAt.IsZero():
+			var left []int
+			for _, pid := range c.tree {
+				if running[pid] {
+					left = append(left, pid)
+				}
+			}
+			if len(left) > 0 && now.Before(c.killAt) {
+				continue
+			}
+			for _, pid := range left {
+				_ = b.kill(pid, syscall.SIGKILL)
+			}
+			if len(left) > 0 {
+				b.recordLocked(blockHit{app: c.app, pid: left[0], name: c.root.displayName(), at: now, forced: true})
+			}
+			b.forgetLocked(root)
+		}
+	}
+}
+
+// spareLocked drops trees still in their warning, for a pause or a switch
+// of blocklist. Trees already sent SIGTERM are seen through.
+func (b *procBlocker) spareLocked() {
+	for root, c := range b.closing {
+		if c.killAt.IsZero() {
+			b.forgetLocked(root)
+		}
+	}
+}
+
+func (b *procBlocker) forgetLocked(root int) {
+	delete(b.closing, root)
+	for pid, r := range b.doomed {
+		if r == root {
+			delete(b.doomed, pid)
+		}
+	}
+}
+
+// warnings counts down to each app about to be closed.
+func (b *procBlocker) warnings(now time.Time) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []string
+	for _, c := range b.closing {
+		if c.killAt.IsZero() && now.Before(c.termAt) {
+			w := fmt.Sprintf("closing %s in %s", c.app, formatDuration(ceilSecond(c.termAt.Sub(now))))
+			if !slices.Contains(out, w) {
+				out = append(out, w)
 			}
 		}
 	}
+	sort.Strings(out)
+	return out
+}
+
+func (b *procBlocker) recordLocked(hit blockHit) {
+	if !hit.forced {
+		b.counts[hit.app]++
+	}
+	b.hits = append(b.hits, hit)
+	if len(b.hits) > maxBlockHits {
+		b.hits = b.hits[len(b.hits)-maxBlockHits:]
+	}
+}
+
+// report returns a copy of the hits so far and the last scan error.
+func (b *procBlocker) report() ([]blockHit, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]blockHit(nil), b.hits...), b.scanErr
 }
 
-func killApps(apps []string) {
-	for _, app := range apps {
-		_ = exec.Command("pkill", "-x", app).Run()
+// attempts returns how many times each rule found its app running.
+func (b *procBlocker) attempts() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	counts := make(map[string]int, len(b.counts))
+	for app, n := range b.counts {
+		counts[app] = n
 	}
+	return counts
 }
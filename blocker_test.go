@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeProcs is a process table the test edits by hand, and a log of the
+// signals the blocker sent to it.
+type fakeProcs struct {
+	procs []process
+	sent  []string // e.g. "TERM 100"
+}
+
+func (f *fakeProcs) list() ([]process, error) { return slices.Clone(f.procs), nil }
+
+func (f *fakeProcs) kill(pid int, sig syscall.Signal) error {
+	name := map[syscall.Signal]string{syscall.SIGTERM: "TERM", syscall.SIGKILL: "KILL", syscall.SIGSTOP: "STOP", syscall.SIGCONT: "CONT"}[sig]
+	f.sent = append(f.sent, fmt.Sprintf("%s %d", name, pid))
+	return nil
+}
+
+// exit takes pids out of the table.
+func (f *fakeProcs) exit(pids ...int) {
+	f.procs = slices.DeleteFunc(f.procs, func(p process) bool { return slices.Contains(pids, p.pid) })
+}
+
+// signals returns the signals sent since the last call.
+func (f *fakeProcs) signals() string {
+	s := strings.Join(f.sent, ", ")
+	f.sent = nil
+	return s
+}
+
+// discordTree is Discord (100) with a helper (101) that has its own
+// child (102).
+func discordTree() *fakeProcs {
+	return &fakeProcs{procs: []process{
+		{pid: 1, comm: "init"},
+		{pid: 100, ppid: 1, comm: "Discord"},
+		{pid: 101, ppid: 100, comm: "Discord Helper"},
+		{pid: 102, ppid: 101, comm: "crashpad"},
+	}}
+}
+
+func newTestBlocker(f *fakeProcs, mode string) *procBlocker {
+	b := newProcBlocker(nil, nil, nil, mode)
+	b.warning = 3 * time.Second
+	b.grace = 5 * time.Second
+	b.list = f.list
+	b.kill = f.kill
+	return b
+}
+
+func TestBlockWarningExpiry(t *testing.T) {
+	f := discordTree()
+	b := newTestBlocker(f, blockKill)
+	b.block("Discord", f.procs[1])
+	start := time.Now()
+
+	b.escalate(start.Add(time.Second))
+	if got := f.signals(); got != "" {
+		t.Errorf("during the warning: sent %q, want nothing", got)
+	}
+	if got := b.warnings(start.Add(time.Second)); len(got) != 1 || got[0] != "closing Discord in 2s" {
+		t.Errorf("warnings = %q, want closing Discord in 2s", got)
+	}
+	// A helper seen during the warning is closed with its tree, not on its own
+	b.block("Discord", f.procs[2])
+	if len(b.closing) != 1 {
+		t.Errorf("%d trees closing, want 1", len(b.closing))
+	}
+
+	b.escalate(start.Add(3 * time.Second))
+	if got, want := f.signals(), "TERM 100, TERM 101, TERM 102"; got != want {
+		t.Errorf("warning over: sent %q, want %q", got, want)
+	}
+	if got := b.warnings(start.Add(3 * time.Second)); len(got) != 0 {
+		t.Errorf("warnings = %q after SIGTERM, want none", got)
+	}
+	// Everything exits within the grace period, so nothing is killed
+	f.exit(100, 101, 102)
+	b.escalate(start.Add(4 * time.Second))
+	if got := f.signals(); got != "" {
+		t.Errorf("after exiting: sent %q, want nothing", got)
+	}
+	if len(b.closing) != 0 || len(b.doomed) != 0 {
+		t.Errorf("closing %v, doomed %v, want both empty", b.closing, b.doomed)
+	}
+	if n := b.attempts()["Discord"]; n != 1 {
+		t.Errorf("attempts = %d, want 1", n)
+	}
+}
+
+func TestBlockRootExitsDuringWarning(t *testing.T) {
+	f := discordTree()
+	b := newTestBlocker(f, blockKill)
+	b.block("Discord", f.procs[1])
+	start := time.Now()
+
+	// Quitting Discord leaves the helpers behind for a moment
+	f.exit(100)
+	b.escalate(start.Add(3 * time.Second))
+	if got := f.signals(); got != "" {
+		t.Errorf("sent %q, want nothing once Discord quit on its own", got)
+	}
+	if len(b.closing) != 0 || len(b.doomed) != 0 {
+		t.Errorf("closing %v, doomed %v, want both empty", b.closing, b.doomed)
+	}
+	if hits, _ := b.report(); len(hits) != 0 {
+		t.Errorf("recorded %d hits, want none", len(hits))
+	}
+}
+
+func TestBlockKillsLeftovers(t *testing.T) {
+	f := discordTree()
+	b := newTestBlocker(f, blockKill)
+	b.block("Discord", f.procs[1])
+	start := time.Now()
+	b.escalate(start.Add(3 * time.Second))
+	f.signals()
+
+	// The crashpad handler ignores SIGTERM
+	f.exit(100, 101)
+	b.escalate(start.Add(7 * time.Second))
+	if got := f.signals(); got != "" {
+		t.Errorf("within the grace period: sent %q, want nothing", got)
+	}
+	b.escalate(start.Add(8 * time.Second))
+	if got, want := f.signals(), "KILL 102"; got != want {
+		t.Errorf("grace over: sent %q, want %q", got, want)
+	}
+	if r := b.Report(); r.notice != "force-killed Discord (pid 102) after 5s" {
+		t.Errorf("notice = %q", r.notice)
+	}
+	if len(b.closing) != 0 || len(b.doomed) != 0 {
+		t.Errorf("closing %v, doomed %v, want both empty", b.closing, b.doomed)
+	}
+}
+
+func TestBlockPauseSparesWarned(t *testing.T) {
+	f := discordTree()
+	b := newTestBlocker(f, blockKill)
+	b.block("Discord", f.procs[1])
+	start := time.Now()
+
+	b.setPaused(true)
+	b.escalate(start.Add(3 * time.Second))
+	if got := f.signals(); got != "" {
+		t.Errorf("sent %q, want nothing after pausing during the warning", got)
+	}
+	if got := b.warnings(start); len(got) != 0 {
+		t.Errorf("warnings = %q, want none", got)
+	}
+}
+
+func TestAllowanceAcrossPause(t *testing.T) {
+	rule, err := parseMatchRule("Slack:1m/hour")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := newTestBlocker(&fakeProcs{}, blockKill)
+	t0 := time.Now()
+	b.started = t0
+	at := func(sec int) time.Time { return t0.Add(time.Duration(sec) * time.Second) }
+
+	b.spend(rule, at(0))
+	b.spend(rule, at(5))
+	b.setPaused(true)
+	b.setPaused(false)
+	// Seen again ten minutes after the pause: none of that is charged
+	b.spend(rule, at(600))
+	b.spend(rule, at(605))
+	// Nor is a gap too long to be a sweep, like a suspend
+	b.spend(rule, at(900))
+	if used := b.allowances[rule.raw].used; used != 10*time.Second {
+		t.Errorf("used %s, want 10s", used)
+	}
+
+	for sec := 905; sec < 950; sec += 5 {
+		if !b.spend(rule, at(sec)) {
+			t.Fatalf("allowance ran out at %ds, with %s used", sec, b.allowances[rule.raw].used)
+		}
+	}
+	if b.spend(rule, at(950)) {
+		t.Errorf("allowance left after using %s of 1m", b.allowances[rule.raw].used)
+	}
+}
+
+func TestAllowanceWindowThaws(t *testing.T) {
+	rule, err := parseMatchRule("Slack:10s/hour")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeProcs{procs: []process{
+		{pid: 200, ppid: 1, comm: "slack"},
+		{pid: 300, ppid: 1, comm: "Discord"},
+	}}
+	b := newTestBlocker(f, blockFreeze)
+	t0 := time.Now()
+	b.started = t0
+
+	b.spend(rule, t0)
+	if b.spend(rule, t0.Add(10*time.Second)) {
+		t.Fatal("allowance left after using all of it")
+	}
+	b.block(rule.raw, f.procs[0])
+	b.block("Discord", f.procs[1])
+	if got, want := f.signals(), "STOP 200, STOP 300"; got != want {
+		t.Fatalf("sent %q, want %q", got, want)
+	}
+
+	// The next hour brings a fresh allowance and continues Slack only
+	if !b.spend(rule, t0.Add(time.Hour)) {
+		t.Error("no allowance in the next window")
+	}
+	if got, want := f.signals(), "CONT 200"; got != want {
+		t.Errorf("new window: sent %q, want %q", got, want)
+	}
+	if _, ok := b.frozen[300]; !ok {
+		t.Error("Discord was thawed with Slack's allowance")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Blocker is one way of keeping distractions away during a session. The
+// model pauses blockers while the session is paused or on a break, and
+// stops them when it ends.
+type Blocker interface {
+	Name() string
+	// Start begins blocking. An error means the session can't run as asked.
+	Start() error
+	Pause()
+	Resume()
+	// Stop undoes whatever the blocker changed. It is safe to call more
+	// than once, and before Start.
+	Stop()
+	Report() blockerReport
+}
+
+// phaseBlocker is a Blocker with its own idea of what to block on
+// pomodoro breaks. The model switches it between work and break rather
+// than pausing it for breaks.
+type phaseBlocker interface {
+	Blocker
+	SetBreak(onBreak bool)
+}
+
+// blockerReport is a blocker's status for the model and for the summary
+// printed when the session ends.
+type blockerReport struct {
+	labels   []string       // shown under the timer, e.g. "🔒 Slack ×2"
+	notice   string         // newest event worth showing, e.g. "closed Slack (pid 812)"
+	warnings []string       // countdowns to apps about to be closed
+	noticeAt time.Time      // when notice happened
+	attempts map[string]int // blocked-app sightings by rule, saved to history
+	summary  []string       // lines printed when the session ends
+	err      error          // why the blocker isn't working, if it isn't
+}
+
+// blockerRegistry lists every backend in the order they start. Each
+// builds its blocker from the session config, or returns nil when the
+// session doesn't use it, so a session can combine any of them. Apps are
+// one backend whose block mode decides whether they are closed or frozen.
+var blockerRegistry = []func(cfg config) Blocker{
+	buildProcBlocker,
+	func(cfg config) Blocker {
+		if len(cfg.blockSites) == 0 {
+			return nil
+		}
+		return newHostsBlocker(cfg.hostsFile, cfg.blockSites)
+	},
+	func(cfg config) Blocker {
+		if cfg.blockCommand == "" {
+			return nil
+		}
+		return newCommandBlocker(cfg.blockCommand)
+	},
+}
+
+func buildProcBlocker(cfg config) Blocker {
+	if len(cfg.blockRules) == 0 && len(cfg.allowRules) == 0 && len(cfg.breakBlockRules) == 0 {
+		return nil
+	}
+	var allow *allowList
+	if len(cfg.allowRules) > 0 {
+		allow = newAllowList(cfg.allowRules)
+	}
+	b := newProcBlocker(cfg.blockRules, cfg.breakBlockRules, allow, cfg.blockMode)
+	b.warning, b.grace = cfg.blockWarning, cfg.blockGrace
+	return b
+}
+
+// newBlockers builds the blockers cfg asks for.
+func newBlockers(cfg config) []Blocker {
+	var blockers []Blocker
+	for _, build := range blockerRegistry {
+		if b := build(cfg); b != nil {
+			blockers = append(blockers, b)
+		}
+	}
+	return blockers
+}
+
+// startBlockers starts each blocker in turn. A session starting on a
+// pomodoro break starts blockers with break rules on them and pauses the
+// rest. If one fails, the ones already started are stopped again.
+func startBlockers(blockers []Blocker, onBreak bool) error {
+	for i, b := range blockers {
+		pb, switches := b.(phaseBlocker)
+		if switches && onBreak {
+			pb.SetBreak(true)
+		}
+		if err := b.Start(); err != nil {
+			for _, started := range blockers[:i] {
+				started.Stop()
+			}
+			b.Stop()
+			return fmt.Errorf("%s blocker: %v", b.Name(), err)
+		}
+		if onBreak && !switches {
+			b.Pause()
+		}
+	}
+	return nil
+}
+
+// stopBlockers stops every blocker and returns the errors they report.
+func stopBlockers(blockers []Blocker) []error {
+	var errs []error
+	for _, b := range blockers {
+		b.Stop()
+		if err := b.Report().err; err != nil {
+			errs = append(errs, fmt.Errorf("%s blocker: %v", b.Name(), err))
+		}
+	}
+	return errs
+}
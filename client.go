@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var errNoSession = errors.New("no running session")
+
+// findSession returns the control socket of the session with the given
+// pid, or of the most recently started live session when pid is 0.
+// Sockets left behind by dead processes are removed along the way.
+func findSession(pid int) (string, error) {
+	if pid != 0 {
+		path := controlSocketPath(pid)
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("no session with pid %d", pid)
+		}
+		return path, nil
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(controlDir(), "*.sock"))
+	var newest string
+	var newestAt time.Time
+	for _, path := range paths {
+		pid, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(path), ".sock"))
+		if err != nil {
+			continue
+		}
+		if !processAlive(pid) {
+			os.Remove(path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestAt) {
+			newest, newestAt = path, info.ModTime()
+		}
+	}
+	if newest == "" {
+		return "", errNoSession
+	}
+	return newest, nil
+}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// sendControl sends one request to the session at path and returns its reply.
+func sendControl(path string, req controlRequest) (controlResponse, error) {
+	var resp controlResponse
+	conn, err := net.DialTimeout("unix", path, controlTimeout)
+	if err != nil {
+		return resp, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * controlTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return resp, err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if errors.Is(err, io.EOF) {
+		return resp, errors.New("session ended")
+	}
+	if err != nil {
+		return resp, err
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return resp, err
+	}
+	if !resp.OK {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// runClient implements the status, pause, resume and stop subcommands.
+func runClient(name string, args []string) {
+	var jsonOut bool
+	var pid int
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-h", "--help":
+			printClientUsage()
+			os.Exit(0)
+		case "--json":
+			jsonOut = true
+		case "--pid":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "error: --pid requires an argument")
+				os.Exit(1)
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "error: invalid pid %q\n", args[i])
+				os.Exit(1)
+			}
+			pid = n
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown argument %q\n", args[i])
+			printClientUsage()
+			os.Exit(1)
+		}
+	}
+
+	path, err := findSession(pid)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lockin: %v\n", err)
+		os.Exit(1)
+	}
+
+	cmd := name
+	if name == "stop" {
+		cmd = "quit"
+	}
+	resp, err := sendControl(path, controlRequest{Cmd: cmd})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lockin: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOut {
+		out := any(resp.Status)
+		if resp.Status == nil {
+			out = resp
+		}
+		_ = json.NewEncoder(os.Stdout).Encode(out)
+		return
+	}
+	if resp.Status == nil {
+		fmt.Println("lockin: stopped")
+		return
+	}
+	fmt.Print(formatStatus(*resp.Status))
+}
+
+func formatStatus(st sessionStatus) string {
+	var b strings.Builder
+	remaining := time.Duration(st.RemainingSec) * time.Second
+	total := time.Duration(st.TotalSec) * time.Second
+	fmt.Fprintf(&b, "%-10s %s of %s\n", "remaining", formatDuration(remaining), formatDuration(total))
+	if st.Task != "" {
+		fmt.Fprintf(&b, "%-10s %s\n", "task", st.Task)
+	}
+	state := "running"
+	if st.Paused {
+		state = "paused"
+	}
+	fmt.Fprintf(&b, "%-10s %s\n", "state", state)
+	if until, err := time.Parse(time.RFC3339, st.Until); err == nil {
+		fmt.Fprintf(&b, "%-10s %s\n", "until", until.Format("15:04"))
+	}
+	if st.Phase != "" {
+		fmt.Fprintf(&b, "%-10s %s %d/%d\n", "phase", st.Phase, st.Cycle, st.Cycles)
+	}
+	fmt.Fprintf(&b, "%-10s %d\n", "pid", st.PID)
+	return b.String()
+}
+
+func printClientUsage() {
+	fmt.Fprintln(os.Stderr, `Usage: lockin status|pause|resume|stop [flags]
+
+Controls a running session.
+
+Flags:
+  --json                   Print the session status as JSON
+  --pid N                  Target the session with this pid (default: newest)`)
+}
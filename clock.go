@@ -0,0 +1,20 @@
+package main
+
+import "time"
+
+// clock is the model's source of time. Everything that animates or counts
+// down reads it instead of calling time.Now, so a session can be driven
+// by a fake clock and rendered deterministically.
+type clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// bootClock is a clock that also knows the time since boot, counting
+// suspend, so suspends can be told apart from the wall clock being set.
+type bootClock interface {
+	SinceBoot() time.Duration
+}
@@ -0,0 +1,19 @@
+//go:build linux
+
+package main
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// SinceBoot reads CLOCK_BOOTTIME, which unlike the monotonic clock keeps
+// counting while the system is suspended.
+func (systemClock) SinceBoot() time.Duration {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_BOOTTIME, &ts); err != nil {
+		return 0
+	}
+	return time.Duration(ts.Nano())
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commandTimeout bounds each run of a custom block command.
+const commandTimeout = 30 * time.Second
+
+// commandBlocker hands blocking to a user command, run through the shell
+// with the event as its argument: start, pause, resume or stop. That's
+// enough to drive a focus mode, a firewall, or anything else lockin
+// doesn't know about.
+//
+//	--block-command '~/bin/focus-mode'   runs: ~/bin/focus-mode start
+type commandBlocker struct {
+	command string
+	events  chan string // pause and resume, run in order off the UI thread
+	done    chan struct{}
+
+	mu      sync.Mutex
+	started bool
+	stopped bool
+	err     error // from the latest run
+}
+
+func newCommandBlocker(command string) *commandBlocker {
+	return &commandBlocker{command: command, events: make(chan string, 16), done: make(chan struct{})}
+}
+
+func (c *commandBlocker) Name() string { return "command" }
+
+// Start runs the start event and waits for it, so a failing command
+// stops the session from starting.
+func (c *commandBlocker) Start() error {
+	if err := c.run("start"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.started = true
+	c.mu.Unlock()
+	go func() {
+		defer close(c.done)
+		for event := range c.events {
+			c.run(event)
+		}
+	}()
+	return nil
+}
+
+func (c *commandBlocker) Pause()  { c.queue("pause") }
+func (c *commandBlocker) Resume() { c.queue("resume") }
+
+func (c *commandBlocker) queue(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.started || c.stopped {
+		return
+	}
+	select {
+	case c.events <- event:
+	default:
+		c.err = fmt.Errorf("dropped %s event, the command is too slow", event)
+	}
+}
+
+// Stop waits for queued events, then runs the stop event.
+func (c *commandBlocker) Stop() {
+	c.mu.Lock()
+	if !c.started || c.stopped {
+		c.mu.Unlock()
+		return
+	}
+	c.stopped = true
+	close(c.events)
+	c.mu.Unlock()
+
+	<-c.done
+	c.run("stop")
+}
+
+func (c *commandBlocker) run(event string) error {
+	err := c.runCommand(event)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", event, err)
+	}
+
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	return err
+}
+
+// runCommand runs the command for event. Its output goes to an unlinked file
+// rather than a pipe, as hooks' does, so a child it leaves in the
+// background doesn't hold up Wait.
+func (c *commandBlocker) runCommand(event string) error {
+	out, err := os.CreateTemp("", "lockin-command-*")
+	if err != nil {
+		return err
+	}
+	os.Remove(out.Name())
+	defer out.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	// The command is a shell snippet, so $1 is the event
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.command+` "$@"`, "sh", event)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		buf := make([]byte, hookOutputMax)
+		n, _ := out.ReadAt(buf, 0)
+		if msg := strings.TrimSpace(string(buf[:n])); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *commandBlocker) Report() blockerReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return blockerReport{labels: []string{"⚙ " + c.command}, err: c.err}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandBlockerBackgroundChild(t *testing.T) {
+	c := newCommandBlocker(`case $1 in start) sleep 3 & ;; esac; true`)
+	begin := time.Now()
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	c.Stop()
+	if took := time.Since(begin); took > time.Second {
+		t.Errorf("start and stop took %s, want them not to wait for the background sleep", took)
+	}
+}
+
+func TestCommandBlockerError(t *testing.T) {
+	c := newCommandBlocker(`echo "no focus mode here" >&2; exit 3`)
+	err := c.Start()
+	if err == nil {
+		t.Fatal("Start succeeded, want an error")
+	}
+	if want := "start: exit status 3: no focus mode here"; err.Error() != want {
+		t.Errorf("Start error = %q, want %q", err, want)
+	}
+	if r := c.Report(); r.err == nil || !strings.Contains(r.err.Error(), "no focus mode here") {
+		t.Errorf("Report error = %v, want the command's output", r.err)
+	}
+}
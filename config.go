@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// set applies one setting by its config file key. Command line flags go
+// through here too, so both sources share the same validation.
+func (cfg *config) set(key string, vals []string) error {
+	val := strings.Join(vals, " ")
+	switch key {
+	case "duration":
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %v", val, err)
+		}
+		if d <= 0 {
+			return errors.New("duration must be positive")
+		}
+		cfg.duration = d
+	case "task":
+		cfg.taskName = val
+	case "block":
+		rules, err := parseMatchRules(vals)
+		if err != nil {
+			return err
+		}
+		cfg.blockApps = vals
+		cfg.blockRules = rules
+	case "break_block":
+		rules, err := parseMatchRules(vals)
+		if err != nil {
+			return err
+		}
+		cfg.breakBlock = vals
+		cfg.breakBlockRules = rules
+	case "allow_only":
+		rules, err := parseMatchRules(vals)
+		if err != nil {
+			return err
+		}
+		cfg.allowApps = vals
+		cfg.allowRules = rules
+	case "block_warning", "block_grace":
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid %s %q", key, val)
+		}
+		if key == "block_warning" {
+			cfg.blockWarning = d
+		} else {
+			cfg.blockGrace = d
+		}
+	case "block_sites":
+		var sites []string
+		for _, v := range vals {
+			site, err := parseSite(v)
+			if err != nil {
+				return err
+			}
+			sites = append(sites, site)
+		}
+		cfg.blockSites = sites
+	case "hosts_file":
+		cfg.hostsFile = val
+	case "block_command":
+		cfg.blockCommand = val
+	case "block_mode":
+		switch val {
+		case blockKill, blockFreeze:
+			cfg.blockMode = val
+		default:
+			return fmt.Errorf("unknown block mode %q (use kill or freeze)", val)
+		}
+	case "strict":
+		on, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("invalid strict setting %q (use true or false)", val)
+		}
+		cfg.strictSettings().on = on
+	case "strict_phrase":
+		if strings.TrimSpace(val) == "" {
+			return errors.New("strict phrase must not be empty")
+		}
+		cfg.strictSettings().phrase = strings.TrimSpace(val)
+	case "strict_cooldown", "strict_pause":
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid %s %q", key, val)
+		}
+		if key == "strict_cooldown" {
+			cfg.strictSettings().cooldown = d
+		} else {
+			cfg.strictSettings().pauseLimit = d
+		}
+	case "strict_pauses":
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid strict_pauses %q (use a count, 0 for none)", val)
+		}
+		cfg.strictSettings().pauses = n
+	case "watchdog":
+		on, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("invalid watchdog setting %q (use true or false)", val)
+		}
+		cfg.watchdog = on
+	case "on_start", "on_pause", "on_resume", "on_complete", "on_abort":
+		if cfg.hooks == nil {
+			cfg.hooks = map[string]string{}
+		}
+		cfg.hooks[strings.TrimPrefix(key, "on_")] = val
+	case "viz":
+		switch val {
+		case "bar", "defrag", "binary", "bubble", "merge", "quick":
+			cfg.vizMode = val
+		default:
+			return fmt.Errorf("unknown viz mode %q (use bar, defrag, binary, bubble, merge, or quick)", val)
+		}
+	case "font":
+		switch val {
+		case "block", "slim", "dot":
+			cfg.fontStyle = val
+		default:
+			return fmt.Errorf("unknown font %q (use block, slim, or dot)", val)
+		}
+	case "pomodoro":
+		plan, err := parsePomodoro(val)
+		if err != nil {
+			return err
+		}
+		cfg.pomodoro = plan
+	case "until":
+		t, err := parseUntil(val, time.Now())
+		if err != nil {
+			return err
+		}
+		cfg.until = t
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+// resolve fills in settings derived from others once all sources are applied.
+func (cfg *config) resolve() {
+	if !cfg.until.IsZero() {
+		cfg.pomodoro = pomodoroPlan{}
+		cfg.duration = time.Until(cfg.until)
+	}
+	if cfg.pomodoro.enabled() {
+		// Phase durations come from the plan
+		cfg.duration = cfg.pomodoro.work
+	}
+	if cfg.fontStyle == "" {
+		cfg.fontStyle = "block"
+	}
+}
+
+// configEntry is one key = value line from the config file. Arrays keep
+// their items; scalars are a single item.
+type configEntry struct {
+	key  string
+	vals []string
+	line int
+}
+
+// configFile is a small TOML subset: top-level keys are defaults and
+// [profile.<name>] tables bundle settings selected with --profile.
+//
+//	block = ["Safari", "Messages"]
+//	viz = "binary"
+//
+//	[profile.deep]
+//	duration = "50m"
+//	block = ["Slack", "Discord"]
+type configFile struct {
+	path     string
+	defaults []configEntry
+	profiles map[string][]configEntry
+}
+
+// defaultConfigPath returns $XDG_CONFIG_HOME/lockin/config.toml, falling
+// back to ~/.config when XDG_CONFIG_HOME is unset.
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "lockin", "config.toml")
+}
+
+// loadConfig builds a config from the file's defaults and the named
+// profile. An empty path means the default location, which may be absent.
+func loadConfig(path, profile string) (config, error) {
+	cfg := config{blockWarning: defaultBlockWarning, blockGrace: defaultBlockGrace}
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		if profile != "" {
+			return cfg, fmt.Errorf("unknown profile %q (no config file at %s)", profile, path)
+		}
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	f, err := parseConfigFile(path, data)
+	if err != nil {
+		return cfg, err
+	}
+	if err := f.apply(&cfg, f.defaults); err != nil {
+		return cfg, err
+	}
+	if profile != "" {
+		entries, ok := f.profiles[profile]
+		if !ok {
+			return cfg, fmt.Errorf("unknown profile %q in %s", profile, path)
+		}
+		if err := f.apply(&cfg, entries); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+func (f *configFile) apply(cfg *config, entries []configEntry) error {
+	for _, e := range entries {
+		if err := cfg.set(e.key, e.vals); err != nil {
+			return fmt.Errorf("%s:%d: %v", f.path, e.line, err)
+		}
+	}
+	return nil
+}
+
+func parseConfigFile(path string, data []byte) (*configFile, error) {
+	f := &configFile{path: path, profiles: map[string][]configEntry{}}
+	profile := "" // current [profile.<name>] table, empty for defaults
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name, ok := strings.CutSuffix(strings.TrimPrefix(line, "["), "]")
+			name, isProfile := strings.CutPrefix(strings.TrimSpace(name), "profile.")
+			if !ok || !isProfile || name == "" {
+				return nil, fmt.Errorf("%s:%d: unknown table %q (use [profile.<name>])", path, lineNo, line)
+			}
+			profile = unquote(name)
+			if _, ok := f.profiles[profile]; !ok {
+				f.profiles[profile] = nil
+			}
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, lineNo)
+		}
+		key = strings.TrimSpace(key)
+		raw = strings.TrimSpace(raw)
+		start := lineNo
+
+		// Arrays may span lines until the closing bracket
+		if strings.HasPrefix(raw, "[") {
+			for !strings.HasSuffix(raw, "]") && scanner.Scan() {
+				lineNo++
+				raw += " " + strings.TrimSpace(stripComment(scanner.Text()))
+			}
+		}
+		vals, err := parseConfigValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %v", path, start, key, err)
+		}
+		entry := configEntry{key: key, vals: vals, line: start}
+		if profile == "" {
+			f.defaults = append(f.defaults, entry)
+		} else {
+			f.profiles[profile] = append(f.profiles[profile], entry)
+		}
+	}
+	return f, scanner.Err()
+}
+
+// parseConfigValue parses a quoted string, an array of strings, or a bare
+// word such as true or 25m.
+func parseConfigValue(raw string) ([]string, error) {
+	if inner, ok := strings.CutPrefix(raw, "["); ok {
+		inner, ok = strings.CutSuffix(inner, "]")
+		if !ok {
+			return nil, errors.New("unterminated array")
+		}
+		var vals []string
+		for _, item := range splitArray(inner) {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			vals = append(vals, unquote(item))
+		}
+		return vals, nil
+	}
+	if raw == "" {
+		return nil, errors.New("missing value")
+	}
+	return []string{unquote(raw)}, nil
+}
+
+// splitArray splits array items on commas outside quotes.
+func splitArray(s string) []string {
+	var items []string
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	if len(s) >= 2 && s[0] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	return s
+}
+
+// stripComment removes a trailing # comment that isn't inside quotes.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `# lockin defaults
+viz = "binary"
+block = ["Slack", 'glob:steam*',
+         "re:^a,b$"]   # spans lines
+duration = 50m
+
+[profile.deep]
+block = ["Discord"]
+task = "it's # not a comment"
+
+[profile."exam prep"]
+allow_only = []
+`
+
+func TestParseConfigFile(t *testing.T) {
+	f, err := parseConfigFile("config.toml", []byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []configEntry{
+		{key: "viz", vals: []string{"binary"}, line: 2},
+		{key: "block", vals: []string{"Slack", "glob:steam*", "re:^a,b$"}, line: 3},
+		{key: "duration", vals: []string{"50m"}, line: 5},
+	}
+	checkEntries(t, "defaults", f.defaults, want)
+	checkEntries(t, "deep", f.profiles["deep"], []configEntry{
+		{key: "block", vals: []string{"Discord"}, line: 8},
+		{key: "task", vals: []string{"it's # not a comment"}, line: 9},
+	})
+	if entries, ok := f.profiles["exam prep"]; !ok || len(entries) != 1 || len(entries[0].vals) != 0 {
+		t.Errorf(`profile "exam prep" = %v, %v, want one empty allow_only`, entries, ok)
+	}
+}
+
+func checkEntries(t *testing.T, name string, got, want []configEntry) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: %d entries, want %d: %v", name, len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].key != want[i].key || !slices.Equal(got[i].vals, want[i].vals) || got[i].line != want[i].line {
+			t.Errorf("%s entry %d = %+v, want %+v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"[table]\n":                 "config.toml:1: unknown table",
+		"[profile.]\n":              "config.toml:1: unknown table",
+		"viz = \"bar\"\njust words": "config.toml:2: expected key = value",
+		"block = [\"Slack\"\n":      "config.toml:1: block: unterminated array",
+		"viz =\n":                   "config.toml:1: viz: missing value",
+	}
+	for in, want := range tests {
+		_, err := parseConfigFile("config.toml", []byte(in))
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("parseConfigFile(%q) error = %v, want %q...", in, err, want)
+		}
+	}
+}
+
+func TestLoadConfigProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path, "deep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.vizMode != "binary" || cfg.duration != 50*time.Minute {
+		t.Errorf("defaults not applied: viz %q, duration %s", cfg.vizMode, cfg.duration)
+	}
+	if !slices.Equal(cfg.blockApps, []string{"Discord"}) || cfg.taskName != "it's # not a comment" {
+		t.Errorf("profile not applied: block %v, task %q", cfg.blockApps, cfg.taskName)
+	}
+
+	if _, err := loadConfig(path, "missing"); err == nil {
+		t.Error("loadConfig with an unknown profile succeeded")
+	}
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "none.toml"), ""); err == nil {
+		t.Error("loadConfig with a missing explicit file succeeded")
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages sent into the program by the control socket.
+type setPausedMsg struct{ paused bool }
+type adjustTimeMsg struct{ delta time.Duration }
+type setTaskMsg struct{ name string }
+type quitMsg struct{ hangup bool } // hangup: the terminal closed
+type statusQueryMsg struct{ reply chan sessionStatus }
+
+// sessionStatus is the snapshot returned by the status command.
+type sessionStatus struct {
+	PID          int    `json:"pid"`
+	Task         string `json:"task"`
+	RemainingSec int64  `json:"remaining_sec"`
+	TotalSec     int64  `json:"total_sec"`
+	Paused       bool   `json:"paused"`
+	Done         bool   `json:"done"`
+	Until        string `json:"until,omitempty"`
+	Phase        string `json:"phase,omitempty"`
+	Cycle        int    `json:"cycle,omitempty"`
+	Cycles       int    `json:"cycles,omitempty"`
+	Strict       bool   `json:"strict,omitempty"`
+}
+
+// controlRequest is one command on the socket. Clients may send either a
+// JSON object or a plain line such as "add 5m" or "task write docs".
+type controlRequest struct {
+	Cmd string `json:"cmd"`
+	Arg string `json:"arg,omitempty"`
+}
+
+type controlResponse struct {
+	OK     bool           `json:"ok"`
+	Error  string         `json:"error,omitempty"`
+	Status *sessionStatus `json:"status,omitempty"`
+}
+
+const controlTimeout = 2 * time.Second
+
+// controlDir holds one socket per running session, named by pid.
+func controlDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "lockin")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("lockin-%d", os.Getuid()))
+}
+
+// ensureControlDir creates the control directory and checks it belongs to
+// this user alone. In the shared temp directory another user could have
+// created it first and left files in it for lockin to act on.
+func ensureControlDir() error {
+	dir := controlDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	return checkControlDir(dir)
+}
+
+func checkControlDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() || info.Mode().Perm() != 0o700 || !ownedByUser(info) {
+		return fmt.Errorf("%s must be a directory owned by you with mode 0700", dir)
+	}
+	return nil
+}
+
+// ownedByUser reports whether info's file belongs to the user lockin runs as.
+func ownedByUser(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
+
+func controlSocketPath(pid int) string {
+	return filepath.Join(controlDir(), strconv.Itoa(pid)+".sock")
+}
+
+// listenControl serves the control protocol for p on this process's
+// socket. The returned func closes the listener and removes the socket.
+func listenControl(p *tea.Program) (func(), error) {
+	if err := ensureControlDir(); err != nil {
+		return nil, err
+	}
+	path := controlSocketPath(os.Getpid())
+	_ = os.Remove(path) // stale socket from a previous process with our pid
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveControl(p, conn)
+		}
+	}()
+	return func() {
+		ln.Close()
+		os.Remove(path)
+	}, nil
+}
+
+func serveControl(p *tea.Program, conn net.Conn) {
+	defer conn.Close()
+	enc := json.NewEncoder(conn)
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		req, err := parseControlRequest(line)
+		var resp controlResponse
+		if err != nil {
+			resp = controlResponse{Error: err.Error()}
+		} else {
+			resp = handleControl(p, req)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func parseControlRequest(line string) (controlRequest, error) {
+	var req controlRequest
+	if strings.HasPrefix(line, "{") {
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			return req, fmt.Errorf("invalid request: %v", err)
+		}
+		return req, nil
+	}
+	cmd, arg, _ := strings.Cut(line, " ")
+	req.Cmd = cmd
+	req.Arg = strings.TrimSpace(arg)
+	return req, nil
+}
+
+// handleControl forwards a request into the program and replies with the
+// resulting status.
+func handleControl(p *tea.Program, req controlRequest) controlResponse {
+	switch req.Cmd {
+	case "status":
+	case "pause":
+		p.Send(setPausedMsg{paused: true})
+	case "resume":
+		p.Send(setPausedMsg{paused: false})
+	case "add", "sub":
+		d, err := time.ParseDuration(req.Arg)
+		if err != nil || d <= 0 {
+			return controlResponse{Error: fmt.Sprintf("invalid duration %q", req.Arg)}
+		}
+		if req.Cmd == "sub" {
+			if st, err := queryStatus(p); err == nil && st.Strict {
+				return controlResponse{Error: "strict session: time can't be taken off"}
+			}
+			d = -d
+		}
+		p.Send(adjustTimeMsg{delta: d})
+	case "task":
+		p.Send(setTaskMsg{name: req.Arg})
+	case "quit":
+		if st, err := queryStatus(p); err == nil && st.Strict {
+			return controlResponse{Error: "strict session: quit from its terminal"}
+		}
+		p.Send(quitMsg{})
+		return controlResponse{OK: true}
+	default:
+		return controlResponse{Error: fmt.Sprintf("unknown command %q (use status, pause, resume, add, sub, task, or quit)", req.Cmd)}
+	}
+
+	st, err := queryStatus(p)
+	if err != nil {
+		return controlResponse{Error: err.Error()}
+	}
+	return controlResponse{OK: true, Status: &st}
+}
+
+func queryStatus(p *tea.Program) (sessionStatus, error) {
+	reply := make(chan sessionStatus, 1)
+	p.Send(statusQueryMsg{reply: reply})
+	select {
+	case st := <-reply:
+		return st, nil
+	case <-time.After(controlTimeout):
+		return sessionStatus{}, errors.New("session did not respond")
+	}
+}
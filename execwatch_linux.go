@@ -0,0 +1,138 @@
+//go:build linux
+
+package main
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Process connector constants from linux/connector.h and linux/cn_proc.h.
+const (
+	cnIdxProc          = 1
+	cnValProc          = 1
+	procCnMcastListen  = 1
+	procCnMcastIgnore  = 2
+	procEventExec      = 0x2
+	nlmsgHdrLen        = 16
+	cnMsgLen           = 20
+	procEventHeaderLen = 16 // what, cpu, timestamp_ns
+)
+
+// watchExecs sends every process that calls exec to found, using the
+// netlink process connector. Subscribing needs CAP_NET_ADMIN, so callers
+// should keep polling when it returns an error. Events that arrive while
+// found is full are dropped; the next poll catches those processes. When
+// the socket overflows and the kernel drops events, it calls lost so the
+// caller can sweep sooner.
+func watchExecs(found chan<- process, lost func()) (func(), error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, err
+	}
+	addr := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: cnIdxProc}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	if err := sendProcCn(fd, procCnMcastListen); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	// Wake up once a second so the reader notices it has been stopped
+	tv := syscall.NsecToTimeval(int64(time.Second))
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	var stopped atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		readExecs(func(buf []byte) (int, error) {
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+			return n, err
+		}, &stopped, found, lost)
+	}()
+
+	return func() {
+		stopped.Store(true)
+		<-done
+		_ = sendProcCn(fd, procCnMcastIgnore)
+		syscall.Close(fd)
+	}, nil
+}
+
+// readExecs reads exec events with recv until stopped is set or recv
+// fails for good. ENOBUFS means the socket overflowed and events were lost,
+// which a busy system can cause, so it reports that and keeps reading.
+func readExecs(recv func([]byte) (int, error), stopped *atomic.Bool, found chan<- process, lost func()) {
+	buf := make([]byte, 4096)
+	for !stopped.Load() {
+		n, err := recv(buf)
+		switch err {
+		case nil:
+		case syscall.EAGAIN, syscall.EINTR:
+			continue
+		case syscall.ENOBUFS:
+			lost()
+			continue
+		default:
+			return
+		}
+		for _, pid := range parseExecEvents(buf[:n]) {
+			p, ok := readProcess(pid)
+			if !ok {
+				continue
+			}
+			select {
+			case found <- p:
+			default:
+			}
+		}
+	}
+}
+
+// sendProcCn sends a listen or ignore request to the process connector.
+func sendProcCn(fd int, op uint32) error {
+	buf := make([]byte, nlmsgHdrLen+cnMsgLen+4)
+	ne := binary.NativeEndian
+	ne.PutUint32(buf[0:], uint32(len(buf)))
+	ne.PutUint16(buf[4:], syscall.NLMSG_DONE)
+	ne.PutUint32(buf[12:], uint32(syscall.Getpid()))
+	cn := buf[nlmsgHdrLen:]
+	ne.PutUint32(cn[0:], cnIdxProc)
+	ne.PutUint32(cn[4:], cnValProc)
+	ne.PutUint16(cn[16:], 4)
+	ne.PutUint32(cn[cnMsgLen:], op)
+	return syscall.Sendto(fd, buf, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+}
+
+// parseExecEvents returns the pids of exec events in one netlink datagram.
+func parseExecEvents(data []byte) []int {
+	ne := binary.NativeEndian
+	var pids []int
+	for len(data) >= nlmsgHdrLen {
+		msgLen := int(ne.Uint32(data[0:]))
+		if msgLen < nlmsgHdrLen || msgLen > len(data) {
+			break
+		}
+		ev := data[nlmsgHdrLen:msgLen]
+		if len(ev) >= cnMsgLen+procEventHeaderLen+8 {
+			ev = ev[cnMsgLen:]
+			if ne.Uint32(ev[0:]) == procEventExec {
+				// exec data: process_pid, process_tgid
+				pids = append(pids, int(ne.Uint32(ev[procEventHeaderLen+4:])))
+			}
+		}
+		aligned := (msgLen + 3) &^ 3 // NLMSG_ALIGN
+		if aligned >= len(data) {
+			break
+		}
+		data = data[aligned:]
+	}
+	return pids
+}
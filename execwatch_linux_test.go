@@ -0,0 +1,81 @@
+//go:build linux
+
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+)
+
+// procEventMsg builds one netlink message carrying a process connector
+// event of kind what about tgid.
+func procEventMsg(what uint32, tgid int) []byte {
+	ne := binary.NativeEndian
+	msg := make([]byte, nlmsgHdrLen+cnMsgLen+procEventHeaderLen+8)
+	ne.PutUint32(msg[0:], uint32(len(msg)))
+	ev := msg[nlmsgHdrLen+cnMsgLen:]
+	ne.PutUint32(ev[0:], what)
+	ne.PutUint32(ev[procEventHeaderLen:], uint32(tgid+1)) // thread pid
+	ne.PutUint32(ev[procEventHeaderLen+4:], uint32(tgid))
+	return msg
+}
+
+func TestParseExecEvents(t *testing.T) {
+	const procEventFork = 0x1
+	var data []byte
+	data = append(data, procEventMsg(procEventExec, 100)...)
+	data = append(data, procEventMsg(procEventFork, 200)...)
+	data = append(data, procEventMsg(procEventExec, 300)...)
+
+	got := parseExecEvents(data)
+	if len(got) != 2 || got[0] != 100 || got[1] != 300 {
+		t.Errorf("parseExecEvents = %v, want [100 300]", got)
+	}
+
+	// A truncated message ends the datagram without reading past it
+	if got := parseExecEvents(data[:len(data)-4]); len(got) != 1 || got[0] != 100 {
+		t.Errorf("truncated: parseExecEvents = %v, want [100]", got)
+	}
+	if got := parseExecEvents(nil); len(got) != 0 {
+		t.Errorf("empty: parseExecEvents = %v, want none", got)
+	}
+}
+
+func TestReadExecsLostEvents(t *testing.T) {
+	// The socket overflows, then delivers an exec of this process, then breaks
+	reads := []struct {
+		data []byte
+		err  error
+	}{
+		{nil, syscall.ENOBUFS},
+		{nil, syscall.EINTR},
+		{procEventMsg(procEventExec, os.Getpid()), nil},
+		{nil, syscall.EBADF},
+	}
+	recv := func(buf []byte) (int, error) {
+		if len(reads) == 0 {
+			t.Fatal("readExecs kept reading after a fatal error")
+		}
+		r := reads[0]
+		reads = reads[1:]
+		return copy(buf, r.data), r.err
+	}
+
+	var stopped atomic.Bool
+	found := make(chan process, 4)
+	lost := 0
+	readExecs(recv, &stopped, found, func() { lost++ })
+
+	if lost != 1 {
+		t.Errorf("lost called %d times, want once for ENOBUFS", lost)
+	}
+	if len(found) != 1 {
+		t.Fatalf("found %d processes, want the one exec after the overflow", len(found))
+	}
+	if p := <-found; p.pid != os.Getpid() {
+		t.Errorf("found pid %d, want %d", p.pid, os.Getpid())
+	}
+}
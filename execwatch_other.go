@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// watchExecs needs the Linux process connector; elsewhere the blocker
+// only polls.
+func watchExecs(found chan<- process, lost func()) (func(), error) {
+	return nil, errors.New("exec events are only available on Linux")
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionRecord is one line of the history file.
+type sessionRecord struct {
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	PlannedSec int64          `json:"planned_sec"`
+	ActualSec  int64          `json:"actual_sec"` // focused time, excluding pauses and breaks
+	Task       string         `json:"task,omitempty"`
+	Pauses     int            `json:"pauses"`
+	PausedSec  int64          `json:"paused_sec,omitempty"`
+	Blocked    []string       `json:"blocked,omitempty"`
+	BreakBlock []string       `json:"break_blocked,omitempty"` // blocked during breaks
+	Attempts   map[string]int `json:"attempts,omitempty"`      // times each blocked app was found running
+	Sites      []string       `json:"sites,omitempty"`
+	Allowed    []string       `json:"allowed,omitempty"` // --allow-only list
+	Outcome    string         `json:"outcome"`           // "completed", "quit" or "abandoned"
+	Reason     string         `json:"reason,omitempty"`  // why a strict session was abandoned
+	Pomodoro   string         `json:"pomodoro,omitempty"`
+	Cycles     int            `json:"cycles,omitempty"` // completed work phases
+}
+
+const (
+	outcomeCompleted = "completed"
+	outcomeQuit      = "quit"
+	outcomeAbandoned = "abandoned" // a strict session left early
+)
+
+func (r sessionRecord) planned() time.Duration { return time.Duration(r.PlannedSec) * time.Second }
+func (r sessionRecord) actual() time.Duration  { return time.Duration(r.ActualSec) * time.Second }
+
+// historyPath returns $XDG_DATA_HOME/lockin/history.jsonl, falling back
+// to ~/.local/share when XDG_DATA_HOME is unset.
+func historyPath() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "lockin", "history.jsonl"), nil
+}
+
+func appendHistory(rec sessionRecord) error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readHistory loads every record in the history file. A missing file is
+// an empty history; malformed lines are skipped.
+func readHistory() ([]sessionRecord, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []sessionRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec sessionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// historyFilter selects records by start date and task name.
+type historyFilter struct {
+	since time.Time // inclusive, zero for no bound
+	until time.Time // exclusive, zero for no bound
+	task  string    // case-insensitive substring
+}
+
+func (f historyFilter) match(rec sessionRecord) bool {
+	if !f.since.IsZero() && rec.Start.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !rec.Start.Before(f.until) {
+		return false
+	}
+	if f.task != "" && !strings.Contains(strings.ToLower(rec.Task), strings.ToLower(f.task)) {
+		return false
+	}
+	return true
+}
+
+// parseDay parses YYYY-MM-DD, "today", "yesterday" or "Nd" (N days ago)
+// into local midnight of that day.
+func parseDay(s string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch s {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil && strings.HasSuffix(s, "d") && n >= 0 {
+		return today.AddDate(0, 0, -n), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD, today, yesterday, or Nd)", s)
+	}
+	return t, nil
+}
+
+func runLog(args []string) {
+	var filter historyFilter
+	var jsonOut bool
+	now := time.Now()
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-h", "--help":
+			printLogUsage()
+			os.Exit(0)
+		case "--since", "--until", "--task":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: %s requires an argument\n", args[i])
+				os.Exit(1)
+			}
+			flag := args[i]
+			i++
+			if flag == "--task" {
+				filter.task = args[i]
+				continue
+			}
+			day, err := parseDay(args[i], now)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			if flag == "--since" {
+				filter.since = day
+			} else {
+				filter.until = day.AddDate(0, 0, 1)
+			}
+		case "--json":
+			jsonOut = true
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown argument %q\n", args[i])
+			printLogUsage()
+			os.Exit(1)
+		}
+	}
+
+	records, err := readHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, rec := range records {
+		if !filter.match(rec) {
+			continue
+		}
+		if jsonOut {
+			_ = enc.Encode(rec)
+			continue
+		}
+		fmt.Println(formatRecord(rec))
+	}
+}
+
+func formatRecord(rec sessionRecord) string {
+	start := rec.Start.Local()
+	line := fmt.Sprintf("%s %s–%s  %7s / %-7s %-9s",
+		start.Format("2006-01-02"),
+		start.Format("15:04"),
+		rec.End.Local().Format("15:04"),
+		formatDuration(rec.actual()),
+		formatDuration(rec.planned()),
+		rec.Outcome)
+	if rec.Task != "" {
+		line += "  " + rec.Task
+	}
+
+	var extras []string
+	if rec.Pomodoro != "" {
+		extras = append(extras, fmt.Sprintf("pomodoro %s, %d done", rec.Pomodoro, rec.Cycles))
+	}
+	switch {
+	case rec.Pauses == 1:
+		extras = append(extras, "1 pause")
+	case rec.Pauses > 1:
+		extras = append(extras, fmt.Sprintf("%d pauses", rec.Pauses))
+	}
+	if len(rec.Blocked) > 0 {
+		extras = append(extras, "blocked "+rec.counted(rec.Blocked))
+	}
+	if len(rec.BreakBlock) > 0 {
+		extras = append(extras, "blocked on breaks "+rec.counted(rec.BreakBlock))
+	}
+	if len(rec.Allowed) > 0 {
+		extras = append(extras, "allowed only "+strings.Join(rec.Allowed, ","))
+	}
+	if rec.Reason != "" {
+		extras = append(extras, fmt.Sprintf("reason: %q", rec.Reason))
+	}
+	if len(extras) > 0 {
+		line += "  (" + strings.Join(extras, "; ") + ")"
+	}
+	return line
+}
+
+// counted joins apps with how often each was blocked, e.g. "Slack×3,Discord".
+func (r sessionRecord) counted(apps []string) string {
+	var out []string
+	for _, app := range apps {
+		if n := r.Attempts[app]; n > 0 {
+			app += fmt.Sprintf("×%d", n)
+		}
+		out = append(out, app)
+	}
+	return strings.Join(out, ",")
+}
+
+// formatDuration renders a duration compactly: 45s, 25m, 12m30s, 1h05m.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	min := int(d.Minutes()) % 60
+	sec := int(d.Seconds()) % 60
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh%02dm", h, min)
+	case min > 0 && sec == 0:
+		return fmt.Sprintf("%dm", min)
+	case min > 0:
+		return fmt.Sprintf("%dm%02ds", min, sec)
+	default:
+		return fmt.Sprintf("%ds", sec)
+	}
+}
+
+func printLogUsage() {
+	fmt.Fprintln(os.Stderr, `Usage: lockin log [flags]
+
+Lists past sessions from the history file.
+
+Flags:
+  --since DATE             Only sessions on or after DATE
+  --until DATE             Only sessions on or before DATE
+  --task TEXT              Only sessions whose task contains TEXT
+  --json                   Print raw JSON lines
+
+DATE is YYYY-MM-DD, today, yesterday, or Nd (N days ago).`)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDay(t *testing.T) {
+	now := time.Date(2026, 3, 1, 14, 30, 0, 0, time.UTC)
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"today", day(3, 1)},
+		{"yesterday", day(2, 28)},
+		{"0d", day(3, 1)},
+		{"1d", day(2, 28)},
+		{"7d", day(2, 22)},
+		{"2026-02-14", day(2, 14)},
+	}
+	for _, tt := range tests {
+		got, err := parseDay(tt.in, now)
+		if err != nil {
+			t.Errorf("parseDay(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDay(%q) = %s, want %s", tt.in, got.Format(dayKey), tt.want.Format(dayKey))
+		}
+	}
+
+	for _, in := range []string{"", "d", "-1d", "3w", "tomorrow", "2026-02-30", "1/3/2026"} {
+		if _, err := parseDay(in, now); err == nil {
+			t.Errorf("parseDay(%q) succeeded, want an error", in)
+		}
+	}
+}
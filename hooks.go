@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hookWait bounds how long lockin lingers at exit so the complete or
+// abort hook can report a failure. Hooks still running are left to it.
+const hookWait = 5 * time.Second
+
+// hookOutputMax is how much of a failed hook's output its error quotes.
+const hookOutputMax = 1024
+
+// hookRunner runs the user's shell commands as the session starts,
+// pauses, resumes, completes or is aborted. Each runs in the background
+// with the session described in LOCKIN_* variables:
+//
+//	--on-start 'notify-send "lockin: $LOCKIN_TASK for $LOCKIN_DURATION"'
+type hookRunner struct {
+	commands map[string]string // by event
+	wg       sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error // failures not yet shown
+}
+
+// newHookRunner returns nil when there are no hooks, which runs nothing.
+func newHookRunner(commands map[string]string) *hookRunner {
+	if len(commands) == 0 {
+		return nil
+	}
+	return &hookRunner{commands: commands}
+}
+
+// run starts the hook for event, if there is one, without waiting for it.
+func (h *hookRunner) run(event string, env []string) {
+	if h == nil || h.commands[event] == "" {
+		return
+	}
+	// Output goes to a file rather than a pipe, so Wait doesn't also wait
+	// for whatever the hook leaves running in the background, like mpv &
+	out, err := os.CreateTemp("", "lockin-hook-*")
+	if err != nil {
+		h.fail(event, err, "")
+		return
+	}
+	os.Remove(out.Name())
+	cmd := exec.Command("sh", "-c", h.commands[event])
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Start(); err != nil {
+		out.Close()
+		h.fail(event, err, "")
+		return
+	}
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer out.Close()
+		if err := cmd.Wait(); err != nil {
+			// ReadAt leaves the offset a background child shares alone
+			buf := make([]byte, hookOutputMax)
+			n, _ := out.ReadAt(buf, 0)
+			h.fail(event, err, string(buf[:n]))
+		}
+	}()
+}
+
+func (h *hookRunner) fail(event string, err error, out string) {
+	if msg := strings.TrimSpace(out); msg != "" {
+		err = fmt.Errorf("on_%s hook: %v: %s", event, err, msg)
+	} else {
+		err = fmt.Errorf("on_%s hook: %v", event, err)
+	}
+	h.mu.Lock()
+	h.errs = append(h.errs, err)
+	h.mu.Unlock()
+}
+
+// takeErrors returns the failures since the last call.
+func (h *hookRunner) takeErrors() []error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	errs := h.errs
+	h.errs = nil
+	return errs
+}
+
+// wait gives running hooks up to timeout to finish.
+func (h *hookRunner) wait(timeout time.Duration) {
+	if h == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// hookEnv describes the session to a hook.
+func (m model) hookEnv(event string) []string {
+	env := []string{
+		"LOCKIN_EVENT=" + event,
+		"LOCKIN_TASK=" + m.taskName,
+		"LOCKIN_DURATION=" + formatDuration(m.totalDuration),
+		"LOCKIN_REMAINING=" + formatDuration(ceilSecond(max(m.remainingAt(m.clock.Now()), 0))),
+	}
+	if m.pomodoro.enabled() {
+		env = append(env, "LOCKIN_PHASE="+m.phase.String())
+	}
+	return env
+}
+
+func (m model) runHook(event string) {
+	m.hooks.run(event, m.hookEnv(event))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -14,11 +15,33 @@ import (
 var version = "dev"
 
 type config struct {
-	duration  time.Duration
-	taskName  string
-	blockApps []string
-	vizMode   string
-	fontStyle string
+	duration        time.Duration
+	taskName        string
+	blockApps       []string // blocklist entries as written
+	blockRules      []matchRule
+	blockMode       string        // blockKill or blockFreeze
+	blockWarning    time.Duration // countdown before closing an app
+	blockGrace      time.Duration // between SIGTERM and SIGKILL
+	breakBlock      []string      // blocklist for pomodoro breaks
+	breakBlockRules []matchRule
+	allowApps       []string
+	allowRules      []matchRule
+	blockSites      []string
+	hostsFile       string // empty for /etc/hosts
+	blockCommand    string // custom blocker, run with start, pause, resume or stop
+	dryRun          bool   // report what the blocklist matches and exit
+	vizMode         string
+	fontStyle       string
+	pomodoro        pomodoroPlan
+	until           time.Time // wall-clock end time, zero for a plain duration
+	strict          strictPlan
+	watchdog        bool              // keep blocking from a detached process if the terminal closes
+	hooks           map[string]string // shell commands by event: start, pause, resume, complete, abort
+	phase           phase             // pomodoro phase to start in, set by lockin attach
+	cycle           int               // its cycle, 0 to start from the first
+
+	clock clock // nil for the system clock
+	seed  int64 // viz shuffle seed, 0 for random
 }
 
 func parseArgs(args []string) config {
@@ -27,8 +50,36 @@ func parseArgs(args []string) config {
 		os.Exit(1)
 	}
 
-	var cfg config
+	// The config file supplies defaults, so find it before applying flags
+	var configPath, profile string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--config", "--profile":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: %s requires an argument\n", args[i])
+				os.Exit(1)
+			}
+			if args[i] == "--config" {
+				configPath = args[i+1]
+			} else {
+				profile = args[i+1]
+			}
+			i++
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	args = rest
+
+	cfg, err := loadConfig(configPath, profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
 	var positional []string
+	cliPomodoro := false
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -38,37 +89,35 @@ func parseArgs(args []string) config {
 		case "-h", "--help":
 			printUsage()
 			os.Exit(0)
-		case "--block":
-			if i+1 >= len(args) {
-				fmt.Fprintln(os.Stderr, "error: --block requires an argument")
-				os.Exit(1)
-			}
-			i++
-			cfg.blockApps = strings.Split(args[i], ",")
-		case "--viz":
+		case "--block-dry-run":
+			cfg.dryRun = true
+		case "--strict":
+			cfg.set("strict", []string{"true"})
+		case "--watchdog":
+			cfg.set("watchdog", []string{"true"})
+		case "--block", "--break-block", "--allow-only", "--block-mode", "--block-warning", "--block-grace", "--block-sites", "--hosts-file", "--block-command", "--on-start", "--on-pause", "--on-resume", "--on-complete", "--on-abort", "--viz", "--font", "--pomodoro":
 			if i+1 >= len(args) {
-				fmt.Fprintln(os.Stderr, "error: --viz requires an argument")
+				fmt.Fprintf(os.Stderr, "error: %s requires an argument\n", args[i])
 				os.Exit(1)
 			}
+			key := strings.ReplaceAll(strings.TrimPrefix(args[i], "--"), "-", "_")
 			i++
-			switch args[i] {
-			case "bar", "defrag", "binary", "bubble", "merge", "quick":
-				cfg.vizMode = args[i]
-			default:
-				fmt.Fprintf(os.Stderr, "error: unknown viz mode %q (use bar, defrag, binary, bubble, merge, or quick)\n", args[i])
-				os.Exit(1)
-			}
-		case "--font":
-			if i+1 >= len(args) {
-				fmt.Fprintln(os.Stderr, "error: --font requires an argument")
-				os.Exit(1)
+			vals := []string{args[i]}
+			switch key {
+			case "block", "break_block", "allow_only", "block_sites":
+				vals = strings.Split(args[i], ",")
+			case "pomodoro":
+				// Accept the cycle count as its own argument: --pomodoro 25m/5m/15m x4
+				if i+1 < len(args) {
+					if _, ok := parseCycles(args[i+1]); ok {
+						i++
+						vals = append(vals, args[i])
+					}
+				}
+				cliPomodoro = true
 			}
-			i++
-			switch args[i] {
-			case "block", "slim", "dot":
-				cfg.fontStyle = args[i]
-			default:
-				fmt.Fprintf(os.Stderr, "error: unknown font %q (use block, slim, or dot)\n", args[i])
+			if err := cfg.set(key, vals); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
 				os.Exit(1)
 			}
 		default:
@@ -76,28 +125,54 @@ func parseArgs(args []string) config {
 		}
 	}
 
-	if len(positional) == 0 {
-		printUsage()
-		os.Exit(1)
+	// lockin until 14:30 [today|tomorrow] [task name]
+	if len(positional) > 0 && positional[0] == "until" {
+		if len(positional) < 2 {
+			fmt.Fprintln(os.Stderr, "error: until requires an end time")
+			os.Exit(1)
+		}
+		spec := positional[1:2]
+		if len(positional) > 2 && (positional[2] == "today" || positional[2] == "tomorrow") {
+			spec = positional[1:3]
+		}
+		if err := cfg.set("until", spec); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		positional = positional[1+len(spec):]
 	}
 
-	d, err := time.ParseDuration(positional[0])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: invalid duration %q: %v\n", positional[0], err)
-		os.Exit(1)
-	}
-	if d <= 0 {
-		fmt.Fprintln(os.Stderr, "error: duration must be positive")
-		os.Exit(1)
+	// The plan sets the phase lengths, so a duration next to it is a mistake
+	// rather than a task name
+	if len(positional) > 0 && cliPomodoro && cfg.until.IsZero() {
+		if _, err := time.ParseDuration(positional[0]); err == nil {
+			fmt.Fprintf(os.Stderr, "error: a duration (%s) can't be combined with --pomodoro\n", positional[0])
+			os.Exit(1)
+		}
 	}
-	cfg.duration = d
 
-	if len(positional) > 1 {
-		cfg.taskName = positional[1]
+	// A leading duration on the command line overrides the config file,
+	// including a pomodoro plan from a profile. Without a duration from
+	// the file, the first positional must be one.
+	if len(positional) > 0 && !cliPomodoro && cfg.until.IsZero() {
+		_, err := time.ParseDuration(positional[0])
+		if err == nil || cfg.duration == 0 && !cfg.pomodoro.enabled() {
+			if err := cfg.set("duration", positional[:1]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			cfg.pomodoro = pomodoroPlan{}
+			positional = positional[1:]
+		}
+	}
+	if len(positional) > 0 {
+		cfg.taskName = positional[0]
 	}
 
-	if cfg.fontStyle == "" {
-		cfg.fontStyle = "block"
+	cfg.resolve()
+	if cfg.duration == 0 && !cfg.dryRun {
+		printUsage()
+		os.Exit(1)
 	}
 
 	return cfg
@@ -105,21 +180,69 @@ func parseArgs(args []string) config {
 
 func printUsage() {
 	fmt.Fprintln(os.Stderr, `Usage: lockin <duration> [task name] [flags]
+       lockin --pomodoro <work/short/long xN> [task name] [flags]
+       lockin until <time> [today|tomorrow] [task name] [flags]
+       lockin log [--since DATE] [--until DATE] [--task TEXT] [--json]
+       lockin stats [--weeks N] [--task TEXT]
+       lockin status|pause|resume|stop [--json] [--pid N]
+       lockin attach            Reopen a session a watchdog is still blocking
+       lockin mcp               Serve MCP tools over stdin/stdout
 
 Duration formats: 30s, 5m, 30m, 1h, 1h30m
+End time formats: 14:30, 9am, 9:15pm
 
 Flags:
   -v, --version            Print version and exit
-  --block App1,App2        Block apps while timer runs
+  --config PATH            Config file (default ~/.config/lockin/config.toml)
+  --profile NAME           Apply a [profile.NAME] table from the config file
+  --block App1,App2        Block apps while timer runs; entries may be
+                           glob:, re:, exe: or cmdline: rules
+  --allow-only App1,App2   Close every other app you start while the timer
+                           runs (shells, terminals and the desktop are kept)
+  --block-mode kill|freeze Close blocked apps, or stop them (SIGSTOP) and
+                           continue them when paused or finished
+  --block-warning 3s       Countdown shown before closing a blocked app
+                           (0 closes it at once)
+  --block-grace 5s         Time a closed app gets to exit before it is
+                           killed outright
+  --block-sites a.com,b.com
+                           Block sites through the hosts file (needs
+                           write access, e.g. sudo)
+  --hosts-file PATH        Hosts file to edit (default /etc/hosts)
+  --block-command CMD      Run CMD start|pause|resume|stop as the session
+                           starts, pauses, resumes and ends
+  --block-dry-run          Show what the blocklist matches right now and
+                           exit without signaling anything
+  --on-start CMD           Run CMD as the session starts; also --on-pause,
+                           --on-resume, --on-complete and --on-abort. Hooks
+                           get LOCKIN_EVENT, LOCKIN_TASK, LOCKIN_DURATION
+                           and LOCKIN_REMAINING
   --viz bar|defrag|binary|bubble|merge|quick
                            Visualization mode
   --font block|slim|dot    Timer font style
+  --pomodoro 25m/5m/15m x4 Cycle work and break phases (blocking only
+                           runs during work)
+  --break-block App1,App2  Block these apps during pomodoro breaks instead
+  --watchdog               Keep blocking until the deadline from a detached
+                           helper if the terminal is closed
+  --strict                 Make quitting early take a typed phrase or a
+                           cooldown plus a reason; limit pauses
 
 Examples:
   lockin 30m "deep work"
   lockin 25m --block Safari,Messages,Discord
+  lockin 25m --block 'exe:/opt/discord/*,re:^chrom(e|ium)$'
+  lockin 50m --block Discord --block-mode freeze
+  lockin 2h "exam prep" --allow-only code,firefox
+  lockin --block 'glob:chrom*,Slack' --block-dry-run
+  sudo lockin 45m --block-sites reddit.com,news.ycombinator.com
   lockin 1h30m --viz defrag
-  lockin 25m --font slim --viz binary`)
+  lockin 25m --font slim --viz binary
+  lockin --pomodoro 25m/5m/15m x4 "deep work" --block Discord
+  lockin --pomodoro 50m/10m --block Discord --break-block code,thunderbird
+  lockin --profile deep "write docs"
+  lockin 90m "thesis" --strict --block Discord
+  lockin until 14:30 "prep for standup"`)
 }
 
 func listenSIGUSR1(p *tea.Program) {
@@ -130,24 +253,158 @@ func listenSIGUSR1(p *tea.Program) {
 	}
 }
 
+// listenSIGHUP ends the session cleanly when the terminal closes, so the
+// blockers are stopped and frozen apps thawed. bubbletea only catches
+// SIGINT and SIGTERM.
+func listenSIGHUP(p *tea.Program) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	for range sig {
+		p.Send(quitMsg{hangup: true})
+	}
+}
+
+func saveHistory(fm model) {
+	if err := appendHistory(fm.record(time.Now())); err != nil {
+		fmt.Fprintf(os.Stderr, "lockin: could not save history: %v\n", err)
+	}
+}
+
+// printBlockerSummary prints what each blocker did during the session.
+func printBlockerSummary(fm model) {
+	for _, b := range fm.blockers {
+		for _, line := range b.Report().summary {
+			fmt.Println("lockin: " + line)
+		}
+	}
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "log":
+			runLog(os.Args[2:])
+			return
+		case "stats":
+			runStats(os.Args[2:])
+			return
+		case "mcp":
+			runMCP()
+			return
+		case "status", "pause", "resume", "stop":
+			runClient(os.Args[1], os.Args[2:])
+			return
+		case "attach":
+			runAttach(os.Args[2:])
+			return
+		case "_watchdog":
+			if len(os.Args) == 3 {
+				runWatchdog(os.Args[2])
+			}
+			return
+		}
+	}
+
 	cfg := parseArgs(os.Args[1:])
+	if cfg.dryRun {
+		runBlockDryRun(cfg)
+		return
+	}
+	runSession(cfg)
+}
+
+// runSession runs cfg in the terminal until it completes or is quit.
+func runSession(cfg config) {
 	m := newModel(cfg)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	for _, warning := range blocklistWarnings(slices.Concat(cfg.blockRules, cfg.breakBlockRules, cfg.allowRules)) {
+		fmt.Fprintf(os.Stderr, "lockin: warning: %s\n", warning)
+		m.setNotice("warning: " + warning)
+	}
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if cfg.strict.enabled() {
+		// Signals go through the model so they can't end the session early
+		opts = append(opts, tea.WithoutSignalHandler())
+	}
+
+	warnOrphans(orphanedSessions())
+	if len(cfg.blockSites) == 0 {
+		// Clean up after sessions that were killed while blocking sites
+		if err := newHostsBlocker(cfg.hostsFile, nil).Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "lockin: could not remove blocked sites: %v\n", err)
+		}
+	}
+	// An attached session may start on a break
+	m.blockBreak = m.phase != phaseWork
+	if err := startBlockers(m.blockers, m.blockBreak); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if m.blockBreak {
+		m.noticeBlockers() // show the break rules from the start
+	}
+
+	if cfg.watchdog && len(m.blockers) > 0 {
+		left, later := m.schedule(m.clock.Now())
+		w, err := startWatchdog(cfg, time.Now().Add(left+later), later)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lockin: watchdog unavailable: %v\n", err)
+		}
+		m.watchdog = w
+	}
+	p := tea.NewProgram(m, opts...)
 
 	go listenSIGUSR1(p)
+	if cfg.strict.enabled() {
+		go listenStrictSignals(p)
+	} else {
+		go listenSIGHUP(p)
+	}
+
+	closeControl, err := listenControl(p)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lockin: control socket unavailable: %v\n", err)
+		closeControl = func() {}
+	}
 
 	finalModel, err := p.Run()
+	closeControl()
+	// Undo blocking even if the program failed
+	for _, err := range stopBlockers(m.blockers) {
+		fmt.Fprintf(os.Stderr, "lockin: %v\n", err)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if fm, ok := finalModel.(model); ok && fm.remaining <= 0 {
-		fmt.Printf("lockin: %s complete", cfg.duration)
+	fm, ok := finalModel.(model)
+	if m.watchdog != nil && (!ok || !fm.hungUp) {
+		// Ended on purpose, so the watchdog has nothing to enforce
+		m.watchdog.release()
+	}
+	if !ok {
+		return
+	}
+	saveHistory(fm)
+	printBlockerSummary(fm)
+	fm.hooks.wait(hookWait)
+	for _, err := range fm.hooks.takeErrors() {
+		fmt.Fprintf(os.Stderr, "lockin: %v\n", err)
+	}
+
+	if fm.remaining <= 0 {
+		if cfg.pomodoro.enabled() {
+			fmt.Printf("lockin: %d × %s pomodoro complete", cfg.pomodoro.cycles, cfg.pomodoro.work)
+		} else if !cfg.until.IsZero() {
+			fmt.Printf("lockin: until %s complete", cfg.until.Format("15:04"))
+		} else {
+			fmt.Printf("lockin: %s complete", cfg.duration)
+		}
 		if cfg.taskName != "" {
 			fmt.Printf(" — %s", cfg.taskName)
 		}
 		fmt.Println()
+	} else if fm.abandonReason != "" {
+		fmt.Printf("lockin: session abandoned after %s — %s\n", formatDuration(fm.focused.Round(time.Second)), fm.abandonReason)
 	}
 }
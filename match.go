@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// matchRule is one blocklist entry. A bare name matches like pkill -x;
+// a prefix selects another way of matching:
+//
+//	Discord              process name
+//	glob:chrom*          process name glob
+//	re:^chrom(e|ium)$    process name regexp
+//	exe:/opt/discord/*   executable path glob (a trailing / matches anything below)
+//	cmdline:~/slack/     substring of the full command line
+//
+// Any rule may end in an allowance, :5m/hour, that lets matching apps run
+// that long in each window before they are blocked.
+type matchRule struct {
+	raw     string
+	kind    string
+	pattern string
+	re      *regexp.Regexp
+	budget  time.Duration // 0 for no allowance
+	window  time.Duration // allowance period, 0 for the whole session
+}
+
+var matchKinds = []string{"glob", "re", "exe", "cmdline"}
+
+func parseMatchRule(s string) (matchRule, error) {
+	s = strings.TrimSpace(s)
+	rule := matchRule{raw: s, kind: "name", pattern: s}
+	body := s
+	if i := strings.LastIndex(s, ":"); i > 0 && budgetPattern.MatchString(s[i+1:]) {
+		budget, window, err := parseBudget(s[i+1:])
+		if err != nil {
+			return rule, fmt.Errorf("invalid allowance in %q: %v", s, err)
+		}
+		rule.budget, rule.window = budget, window
+		body = s[:i]
+		rule.pattern = body
+	}
+	for _, kind := range matchKinds {
+		if pattern, ok := strings.CutPrefix(body, kind+":"); ok {
+			rule.kind = kind
+			rule.pattern = pattern
+			break
+		}
+	}
+	if rule.pattern == "" {
+		return rule, fmt.Errorf("empty block rule %q", s)
+	}
+
+	switch rule.kind {
+	case "glob":
+		if _, err := filepath.Match(rule.pattern, ""); err != nil {
+			return rule, fmt.Errorf("invalid glob in %q: %v", s, err)
+		}
+	case "re":
+		re, err := regexp.Compile(rule.pattern)
+		if err != nil {
+			return rule, fmt.Errorf("invalid regexp in %q: %v", s, err)
+		}
+		rule.re = re
+	case "exe":
+		rule.pattern = expandHome(rule.pattern)
+		if _, err := filepath.Match(rule.pattern, ""); err != nil {
+			return rule, fmt.Errorf("invalid glob in %q: %v", s, err)
+		}
+	case "cmdline":
+		rule.pattern = expandHome(rule.pattern)
+	}
+	return rule, nil
+}
+
+func parseMatchRules(entries []string) ([]matchRule, error) {
+	var rules []matchRule
+	for _, e := range entries {
+		rule, err := parseMatchRule(e)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r matchRule) match(p process) bool {
+	switch r.kind {
+	case "glob":
+		for _, name := range p.names() {
+			if ok, _ := filepath.Match(r.pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	case "re":
+		for _, name := range p.names() {
+			if r.re.MatchString(name) {
+				return true
+			}
+		}
+		return false
+	case "exe":
+		if p.exe == "" {
+			return false
+		}
+		if strings.HasSuffix(r.pattern, "/") {
+			return strings.HasPrefix(p.exe, r.pattern)
+		}
+		ok, _ := filepath.Match(r.pattern, p.exe)
+		return ok
+	case "cmdline":
+		return len(p.cmdline) > 0 && strings.Contains(strings.Join(p.cmdline, " "), r.pattern)
+	default:
+		return p.hasName(r.pattern)
+	}
+}
+
+func (r matchRule) String() string { return r.raw }
+
+// allowance describes the rule's budget, e.g. "5m per hour".
+func (r matchRule) allowance() string {
+	switch r.window {
+	case 0:
+		return formatDuration(r.budget) + " per session"
+	case time.Hour:
+		return formatDuration(r.budget) + " per hour"
+	case 24 * time.Hour:
+		return formatDuration(r.budget) + " per day"
+	}
+	return formatDuration(r.budget) + " per " + formatDuration(r.window)
+}
+
+// budgetPattern recognizes the allowance suffix of a rule, e.g. 5m/hour.
+var budgetPattern = regexp.MustCompile(`^[0-9][^/]*/[a-z0-9.]+$`)
+
+// parseBudget parses an allowance such as 5m/hour, 20m/day, 10m/session
+// or 2m/30m.
+func parseBudget(s string) (budget, window time.Duration, err error) {
+	b, w, _ := strings.Cut(s, "/")
+	budget, err = time.ParseDuration(b)
+	if err != nil || budget <= 0 {
+		return 0, 0, fmt.Errorf("bad duration %q", b)
+	}
+	switch w {
+	case "hour":
+		window = time.Hour
+	case "day":
+		window = 24 * time.Hour
+	case "session":
+	default:
+		window, err = time.ParseDuration(w)
+		if err != nil || window <= 0 {
+			return 0, 0, fmt.Errorf("bad window %q (use hour, day, session or a duration)", w)
+		}
+	}
+	if window > 0 && budget > window {
+		return 0, 0, fmt.Errorf("%s is longer than the %s window", formatDuration(budget), w)
+	}
+	return budget, window, nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMatchRule(t *testing.T) {
+	tests := []struct {
+		in             string
+		kind, pattern  string
+		budget, window time.Duration
+	}{
+		{"Discord", "name", "Discord", 0, 0},
+		{" Slack ", "name", "Slack", 0, 0},
+		{"glob:chrom*", "glob", "chrom*", 0, 0},
+		{"re:^chrom(e|ium)$", "re", "^chrom(e|ium)$", 0, 0},
+		{"re:^a:b$", "re", "^a:b$", 0, 0},
+		{"exe:/opt/discord/", "exe", "/opt/discord/", 0, 0},
+		{"cmdline:--profile work", "cmdline", "--profile work", 0, 0},
+		{"Slack:5m/hour", "name", "Slack", 5 * time.Minute, time.Hour},
+		{"glob:steam*:1h/day", "glob", "steam*", time.Hour, 24 * time.Hour},
+		{"YouTube:10m/session", "name", "YouTube", 10 * time.Minute, 0},
+		{"cmdline:reddit:2m/30m", "cmdline", "reddit", 2 * time.Minute, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		r, err := parseMatchRule(tt.in)
+		if err != nil {
+			t.Errorf("parseMatchRule(%q): %v", tt.in, err)
+			continue
+		}
+		if r.kind != tt.kind || r.pattern != tt.pattern || r.budget != tt.budget || r.window != tt.window {
+			t.Errorf("parseMatchRule(%q) = %s %q %s/%s, want %s %q %s/%s", tt.in,
+				r.kind, r.pattern, r.budget, r.window, tt.kind, tt.pattern, tt.budget, tt.window)
+		}
+	}
+
+	for _, in := range []string{"", "glob:", "glob:[", "re:(", "exe:[", "Slack:5m/fortnight", "Slack:2h/hour", "Slack:0s/hour"} {
+		if _, err := parseMatchRule(in); err == nil {
+			t.Errorf("parseMatchRule(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestParseBudget(t *testing.T) {
+	tests := []struct {
+		in             string
+		budget, window time.Duration
+	}{
+		{"5m/hour", 5 * time.Minute, time.Hour},
+		{"20m/day", 20 * time.Minute, 24 * time.Hour},
+		{"10m/session", 10 * time.Minute, 0},
+		{"2m/30m", 2 * time.Minute, 30 * time.Minute},
+		{"1h/hour", time.Hour, time.Hour},
+	}
+	for _, tt := range tests {
+		budget, window, err := parseBudget(tt.in)
+		if err != nil {
+			t.Errorf("parseBudget(%q): %v", tt.in, err)
+			continue
+		}
+		if budget != tt.budget || window != tt.window {
+			t.Errorf("parseBudget(%q) = %s/%s, want %s/%s", tt.in, budget, window, tt.budget, tt.window)
+		}
+	}
+
+	for _, in := range []string{"5m", "0m/hour", "-5m/hour", "5m/week", "5m/0s", "2h/hour", "5m/"} {
+		if _, _, err := parseBudget(in); err == nil {
+			t.Errorf("parseBudget(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	chromium := process{comm: "chromium-browse", exe: "/usr/lib/chromium/chromium-browser", cmdline: []string{"/usr/lib/chromium/chromium-browser", "--profile-directory=Work"}}
+	tests := []struct {
+		rule string
+		p    process
+		want bool
+	}{
+		{"chromium-browser", chromium, true}, // comm is truncated
+		{"chromium", chromium, false},
+		{"glob:chrom*", chromium, true},
+		{"re:^chrom(e|ium)-", chromium, true},
+		{"exe:/usr/lib/chromium/", chromium, true},
+		{"exe:/usr/lib/*/chromium-browser", chromium, true},
+		{"exe:/opt/", chromium, false},
+		{"cmdline:--profile-directory=Work", chromium, true},
+		{"cmdline:Personal", chromium, false},
+		{"exe:/usr/lib/chromium/", process{comm: "chromium"}, false}, // exe unreadable
+	}
+	for _, tt := range tests {
+		r, err := parseMatchRule(tt.rule)
+		if err != nil {
+			t.Fatalf("parseMatchRule(%q): %v", tt.rule, err)
+		}
+		if got := r.match(tt.p); got != tt.want {
+			t.Errorf("%q matching %s = %v, want %v", tt.rule, tt.p.displayName(), got, tt.want)
+		}
+	}
+}
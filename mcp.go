@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The MCP server speaks newline-delimited JSON-RPC 2.0 on stdin/stdout.
+// Sessions started through it run headless in this process; when none is
+// running, status and control tools fall back to the newest session
+// found on a control socket.
+
+const mcpProtocolVersion = "2024-11-05"
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+func schema(props map[string]any, required ...string) map[string]any {
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "start_session",
+		Description: "Start a focus session that counts down and optionally blocks apps while it runs.",
+		InputSchema: schema(map[string]any{
+			"duration":    map[string]any{"type": "string", "description": "Go duration such as 25m or 1h30m"},
+			"task":        map[string]any{"type": "string", "description": "Task name"},
+			"block":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Apps to block while the timer runs: names or glob:, re:, exe:, cmdline: rules"},
+			"break_block": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Apps to block during pomodoro breaks instead of the work blocklist"},
+			"allow_only":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Close every app the user starts except these (shells, terminals and the desktop are always kept)"},
+			"block_sites": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Sites to block through the hosts file, e.g. reddit.com (needs write access to it)"},
+			"block_mode":  map[string]any{"type": "string", "enum": []string{"kill", "freeze"}, "description": "Close blocked apps (kill, the default) or stop them until the session pauses or ends (freeze)"},
+			"pomodoro":    map[string]any{"type": "string", "description": "Pomodoro plan such as 25m/5m/15m x4, used instead of duration"},
+			"profile":     map[string]any{"type": "string", "description": "Config file profile supplying defaults"},
+			"until":       map[string]any{"type": "string", "description": "Wall-clock end time such as 14:30 or 9am tomorrow, used instead of duration"},
+		}),
+	},
+	{
+		Name:        "get_status",
+		Description: "Report the running session's remaining time, task, paused state and phase.",
+		InputSchema: schema(map[string]any{}),
+	},
+	{
+		Name:        "pause",
+		Description: "Pause the running session.",
+		InputSchema: schema(map[string]any{}),
+	},
+	{
+		Name:        "resume",
+		Description: "Resume the running session.",
+		InputSchema: schema(map[string]any{}),
+	},
+	{
+		Name:        "extend",
+		Description: "Add time to the running session.",
+		InputSchema: schema(map[string]any{
+			"duration": map[string]any{"type": "string", "description": "Go duration to add, such as 10m"},
+		}, "duration"),
+	},
+	{
+		Name:        "stop_session",
+		Description: "End the running session early.",
+		InputSchema: schema(map[string]any{}),
+	},
+	{
+		Name:        "get_history",
+		Description: "List past sessions, newest last.",
+		InputSchema: schema(map[string]any{
+			"since": map[string]any{"type": "string", "description": "YYYY-MM-DD, today, yesterday, or Nd"},
+			"until": map[string]any{"type": "string", "description": "YYYY-MM-DD, today, yesterday, or Nd"},
+			"task":  map[string]any{"type": "string", "description": "Only sessions whose task contains this text"},
+			"limit": map[string]any{"type": "integer", "description": "Return at most this many of the newest sessions"},
+		}),
+	},
+}
+
+type mcpServer struct {
+	mu      sync.Mutex
+	program *tea.Program  // headless session started by this server, nil if none
+	done    chan struct{} // closed once that session's history is saved
+}
+
+func runMCP() {
+	srv := &mcpServer{}
+	enc := json.NewEncoder(os.Stdout)
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if resp, ok := srv.handle(line); ok {
+				_ = enc.Encode(resp)
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(os.Stderr, "lockin: %v\n", err)
+			}
+			srv.stop()
+			return
+		}
+	}
+}
+
+// handle processes one JSON-RPC message. Notifications get no response.
+func (s *mcpServer) handle(line []byte) (rpcResponse, bool) {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{-32700, "parse error"}}, true
+	}
+	if req.ID == nil {
+		return rpcResponse{}, false
+	}
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		protocol := params.ProtocolVersion
+		if protocol == "" {
+			protocol = mcpProtocolVersion
+		}
+		resp.Result = map[string]any{
+			"protocolVersion": protocol,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "lockin", "version": version},
+		}
+	case "ping":
+		resp.Result = map[string]any{}
+	case "tools/list":
+		resp.Result = map[string]any{"tools": mcpTools}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			resp.Error = &rpcError{-32602, "invalid params"}
+			break
+		}
+		result, err := s.callTool(params.Name, params.Arguments)
+		if err != nil {
+			resp.Result = mcpToolResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}
+			break
+		}
+		text, _ := json.Marshal(result)
+		resp.Result = mcpToolResult{Content: []mcpContent{{Type: "text", Text: string(text)}}}
+	default:
+		resp.Error = &rpcError{-32601, fmt.Sprintf("method %q not found", req.Method)}
+	}
+	return resp, true
+}
+
+func (s *mcpServer) callTool(name string, raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		raw = json.RawMessage("{}")
+	}
+	switch name {
+	case "start_session":
+		var args struct {
+			Duration   string   `json:"duration"`
+			Task       string   `json:"task"`
+			Block      []string `json:"block"`
+			BreakBlock []string `json:"break_block"`
+			BlockMode  string   `json:"block_mode"`
+			BlockSites []string `json:"block_sites"`
+			AllowOnly  []string `json:"allow_only"`
+			Pomodoro   string   `json:"pomodoro"`
+			Profile    string   `json:"profile"`
+			Until      string   `json:"until"`
+		}
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+		cfg, err := loadConfig("", args.Profile)
+		if err != nil {
+			return nil, err
+		}
+		if args.Task != "" {
+			cfg.taskName = args.Task
+		}
+		if len(args.Block) > 0 {
+			if err := cfg.set("block", args.Block); err != nil {
+				return nil, err
+			}
+		}
+		if len(args.BreakBlock) > 0 {
+			if err := cfg.set("break_block", args.BreakBlock); err != nil {
+				return nil, err
+			}
+		}
+		if len(args.AllowOnly) > 0 {
+			if err := cfg.set("allow_only", args.AllowOnly); err != nil {
+				return nil, err
+			}
+		}
+		if len(args.BlockSites) > 0 {
+			if err := cfg.set("block_sites", args.BlockSites); err != nil {
+				return nil, err
+			}
+		}
+		if args.BlockMode != "" {
+			if err := cfg.set("block_mode", []string{args.BlockMode}); err != nil {
+				return nil, err
+			}
+		}
+		if args.Until != "" {
+			if err := cfg.set("until", []string{args.Until}); err != nil {
+				return nil, err
+			}
+		} else if args.Pomodoro != "" {
+			if err := cfg.set("pomodoro", []string{args.Pomodoro}); err != nil {
+				return nil, err
+			}
+		} else if args.Duration != "" {
+			if err := cfg.set("duration", []string{args.Duration}); err != nil {
+				return nil, err
+			}
+			cfg.pomodoro = pomodoroPlan{}
+		}
+		cfg.resolve()
+		// A headless session has no terminal to confirm a strict quit in
+		cfg.strict = strictPlan{}
+		if cfg.duration == 0 {
+			return nil, errors.New("duration, until, pomodoro or a profile with a duration is required")
+		}
+		return s.start(cfg)
+	case "get_status":
+		resp, err := s.control(controlRequest{Cmd: "status"})
+		if errors.Is(err, errNoSession) {
+			return map[string]any{"running": false}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resp.Status, nil
+	case "pause", "resume":
+		resp, err := s.control(controlRequest{Cmd: name})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Status, nil
+	case "extend":
+		var args struct {
+			Duration string `json:"duration"`
+		}
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+		resp, err := s.control(controlRequest{Cmd: "add", Arg: args.Duration})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Status, nil
+	case "stop_session":
+		if _, err := s.control(controlRequest{Cmd: "quit"}); err != nil {
+			return nil, err
+		}
+		return map[string]any{"stopped": true}, nil
+	case "get_history":
+		var args struct {
+			Since string `json:"since"`
+			Until string `json:"until"`
+			Task  string `json:"task"`
+			Limit int    `json:"limit"`
+		}
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+		return historyQuery(args.Since, args.Until, args.Task, args.Limit)
+	default:
+		return nil, fmt.Errorf("unknown tool %q", name)
+	}
+}
+
+// start runs cfg as a headless session in this process.
+func (s *mcpServer) start(cfg config) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.program != nil {
+		return nil, errors.New("a session is already running; stop it first")
+	}
+
+	m := newModel(cfg)
+	if err := startBlockers(m.blockers, false); err != nil {
+		return nil, err
+	}
+	p := tea.NewProgram(m,
+		tea.WithInput(nil),
+		tea.WithOutput(io.Discard),
+		tea.WithoutSignalHandler())
+	done := make(chan struct{})
+	s.program = p
+	s.done = done
+
+	closeControl, err := listenControl(p)
+	if err != nil {
+		closeControl = func() {}
+	}
+	go func() {
+		finalModel, err := p.Run()
+		closeControl()
+		stopBlockers(m.blockers)
+		if err == nil {
+			if fm, ok := finalModel.(model); ok {
+				saveHistory(fm)
+			}
+		}
+		s.mu.Lock()
+		s.program = nil
+		s.mu.Unlock()
+		close(done)
+	}()
+
+	return queryStatus(p)
+}
+
+// control sends req to this server's session, or to the newest session
+// on a control socket if this server isn't running one.
+func (s *mcpServer) control(req controlRequest) (controlResponse, error) {
+	s.mu.Lock()
+	p := s.program
+	s.mu.Unlock()
+	if p != nil {
+		resp := handleControl(p, req)
+		if !resp.OK {
+			return resp, errors.New(resp.Error)
+		}
+		return resp, nil
+	}
+
+	path, err := findSession(0)
+	if err != nil {
+		return controlResponse{}, err
+	}
+	return sendControl(path, req)
+}
+
+func (s *mcpServer) stop() {
+	s.mu.Lock()
+	p, done := s.program, s.done
+	s.mu.Unlock()
+	if p != nil {
+		p.Send(quitMsg{})
+		<-done
+	}
+}
+
+func historyQuery(since, until, task string, limit int) ([]sessionRecord, error) {
+	now := time.Now()
+	filter := historyFilter{task: task}
+	if since != "" {
+		day, err := parseDay(since, now)
+		if err != nil {
+			return nil, err
+		}
+		filter.since = day
+	}
+	if until != "" {
+		day, err := parseDay(until, now)
+		if err != nil {
+			return nil, err
+		}
+		filter.until = day.AddDate(0, 0, 1)
+	}
+
+	records, err := readHistory()
+	if err != nil {
+		return nil, err
+	}
+	selected := []sessionRecord{}
+	for _, rec := range records {
+		if filter.match(rec) {
+			selected = append(selected, rec)
+		}
+	}
+	if limit > 0 && len(selected) > limit {
+		selected = selected[len(selected)-limit:]
+	}
+	return selected, nil
+}
@@ -2,8 +2,9 @@ package main
 
 import (
 	"fmt"
+	"math/rand"
+	"os"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,24 +14,66 @@ import (
 type tickMsg time.Time
 type vizTickMsg struct{}
 type togglePauseMsg struct{}
-type blockerStoppedMsg struct{}
 
 type model struct {
 	totalDuration time.Duration
 	remaining     time.Duration
 	taskName      string
 	blockApps     []string
+	breakBlock    []string
+	allowApps     []string
 	vizMode       string
 	font *fontData
 
 	paused bool
 	done   bool
 
+	// remaining is derived from deadline on every tick. While paused the
+	// deadline is frozen relative to pausedAt and pushed back on resume.
+	deadline    time.Time // monotonic end of the current phase
+	pausedAt    time.Time
+	pausedTotal time.Duration
+
+	notice   string // transient message under the timer, e.g. after a suspend
+	noticeAt time.Time
+
+	until time.Time // wall-clock end time for until sessions
+
+	pomodoro   pomodoroPlan
+	phase      phase
+	cycle      int // current work cycle, 1-based
+	cyclesDone int // completed work phases
+
+	startedAt time.Time
+	focused   time.Duration // time spent counting down in work phases
+	pauses    int
+
 	width  int
 	height int
 
-	blockerStop   chan struct{}
-	blockerPaused *atomic.Bool
+	clock clock
+	rng   *rand.Rand // shuffles the defrag and sort grids
+
+	blockers     []Blocker
+	reports      []blockerReport   // one per blocker, refreshed each tick
+	blockPaused  bool              // blockers last synced as paused
+	blockBreak   bool              // blockers last synced as on a break
+	lastNoticeAt time.Time         // newest blocker notice already shown
+	blockErrs    map[string]string // blocker errors already shown, by blocker
+	blockSites   []string
+
+	strict        strictPlan
+	quitting      bool      // strict quit prompt is open
+	quitAt        time.Time // when the prompt opened, for the cooldown
+	quitInput     string
+	askReason     bool   // phrase or cooldown done, now asking why
+	abandonReason string // why a strict session was left early
+	hungUp        bool   // ended because the terminal closed
+
+	watchdog *watchdog // detached blocker that outlives the terminal, or nil
+
+	hooks *hookRunner // nil without hooks
+	ended bool        // shutdown has run
 
 	defragOriginal []uint8 // original random layout: 1=data, 0=free
 	defragWidth    int
@@ -38,7 +81,8 @@ type model struct {
 	sortFrames [][]int // pre-computed animation frames for sort vizs
 	sortWidth  int     // elements per row
 
-	lastTickAt time.Time // wall clock at last second-tick, for sub-second interpolation
+	lastTickAt time.Time     // clock reading at the last second-tick, for suspend detection
+	lastBoot   time.Duration // and the time since boot then, if the clock knows it
 
 	binaryPrevBits []bool      // previous bit states for phosphor fade
 	binaryOnAt     []time.Time // when each bit last turned on
@@ -52,31 +96,139 @@ type model struct {
 }
 
 func newModel(cfg config) model {
+	seed := cfg.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
 	m := model{
 		totalDuration: cfg.duration,
 		remaining:     cfg.duration,
 		taskName:      cfg.taskName,
 		blockApps:     cfg.blockApps,
+		breakBlock:    cfg.breakBlock,
 		vizMode:       cfg.vizMode,
 		font:          fonts[cfg.fontStyle],
-		blockerStop:   make(chan struct{}),
-		blockerPaused: &atomic.Bool{},
+		allowApps:     cfg.allowApps,
+		blockers:      newBlockers(cfg),
+		blockErrs:     map[string]string{},
+		blockSites:    cfg.blockSites,
+		pomodoro:      cfg.pomodoro,
+		strict:        cfg.strict,
+		hooks:         newHookRunner(cfg.hooks),
+		cycle:         1,
+		clock:         cfg.clock,
+		rng:           rand.New(rand.NewSource(seed)),
+	}
+	if cfg.cycle > 0 {
+		m.phase, m.cycle = cfg.phase, cfg.cycle
+	}
+	if m.clock == nil {
+		m.clock = systemClock{}
+	}
+	m.startedAt = m.clock.Now()
+	if !cfg.until.IsZero() {
+		// Measure from now rather than when the arguments were parsed
+		m.until = cfg.until
+		m.totalDuration = cfg.until.Sub(m.startedAt).Round(time.Second)
+		m.remaining = m.totalDuration
+	}
+	m.deadline = m.startedAt.Add(m.totalDuration)
+	m.lastTickAt = m.startedAt
+	if bc, ok := m.clock.(bootClock); ok {
+		m.lastBoot = bc.SinceBoot()
 	}
 	if m.isDotFont() {
 		m.updateDotFade()
 	}
+	for _, b := range m.blockers {
+		m.reports = append(m.reports, b.Report())
+	}
 	return m
 }
 
 func (m model) isDotFont() bool   { return m.font == fonts["dot"] }
 func (m model) isBlockFont() bool { return m.font == fonts["block"] }
 
-func doTick() tea.Cmd {
-	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+// suspendThreshold is how far a clock that counts suspend may run ahead of
+// the monotonic clock between ticks before we treat the gap as a suspend.
+const suspendThreshold = 5 * time.Second
+
+const noticeDuration = 30 * time.Second
+
+// doTick fires just after remaining crosses its next whole second, so the
+// display changes on the second even if earlier ticks were late. It needs
+// the exact time left, not the rounded m.remaining; see tickAlign.
+func doTick(remaining time.Duration) tea.Cmd {
+	delay := remaining % time.Second
+	if delay <= 0 {
+		delay = time.Second
+	}
+	return tea.Tick(delay+5*time.Millisecond, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
+// ceilSecond rounds up so the display reads 25:00 at the start and
+// 00:01 during the final second.
+func ceilSecond(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return (d + time.Second - 1) / time.Second * time.Second
+}
+
+// tickAlign returns the countdown the next tick lines up with: the exact
+// time left while running, and while paused the time left in a strict pause
+// limit, or a plain second since the display doesn't move.
+func (m model) tickAlign(now time.Time) time.Duration {
+	if !m.paused || m.done {
+		return m.remainingAt(now)
+	}
+	if m.strict.enabled() && m.strict.pauseLimit > 0 {
+		return m.strict.pauseLimit - now.Sub(m.pausedAt)
+	}
+	return time.Second
+}
+
+// remainingAt returns the exact time left in the current phase.
+func (m model) remainingAt(now time.Time) time.Duration {
+	switch {
+	case m.done:
+		return m.remaining
+	case m.paused:
+		return m.deadline.Sub(m.pausedAt)
+	default:
+		return m.deadline.Sub(now)
+	}
+}
+
+// suspendedSince reports how long the system slept since the last tick and
+// notes now for the next one. Go's monotonic clock stops during suspend, so
+// it compares that with the time since boot where the clock knows it, and
+// with the wall clock otherwise, which an NTP step forward can fool.
+func (m *model) suspendedSince(now time.Time) time.Duration {
+	last := m.lastTickAt
+	m.lastTickAt = now
+	if bc, ok := m.clock.(bootClock); ok {
+		boot := bc.SinceBoot()
+		elapsed := boot - m.lastBoot
+		m.lastBoot = boot
+		return suspendGap(elapsed, now.Sub(last))
+	}
+	return suspendGap(now.Round(0).Sub(last.Round(0)), now.Sub(last))
+}
+
+// suspendGap returns how long the system slept while elapsed passed on a
+// clock that counts suspend and awake on the monotonic clock, or zero if
+// the difference is too small to be a suspend.
+func suspendGap(elapsed, awake time.Duration) time.Duration {
+	gap := elapsed - awake
+	if gap < suspendThreshold {
+		return 0
+	}
+	return gap
+}
+
 func doVizTick() tea.Cmd {
 	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
 		return vizTickMsg{}
@@ -95,10 +247,8 @@ func (m model) needsFastTick() bool {
 }
 
 func (m model) Init() tea.Cmd {
-	cmds := []tea.Cmd{doTick()}
-	if len(m.blockApps) > 0 {
-		cmds = append(cmds, startBlocker(m.blockApps, m.blockerPaused, m.blockerStop))
-	}
+	m.runHook("start")
+	cmds := []tea.Cmd{doTick(m.tickAlign(m.clock.Now()))}
 	if m.needsFastTick() {
 		cmds = append(cmds, doVizTick())
 	}
@@ -120,31 +270,53 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.quitting {
+			return m.updateQuitPrompt(msg)
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
+			if m.strict.enabled() {
+				m.openQuitPrompt()
+				return m, nil
+			}
 			m.done = true
 			m.shutdown()
 			return m, tea.Quit
 		case " ":
-			m.paused = !m.paused
-			m.blockerPaused.Store(m.paused)
-			if !m.paused && m.needsFastTick() {
-				m.lastTickAt = time.Now()
-				return m, doVizTick()
-			}
-			return m, nil
+			return m, m.togglePause()
 		}
 		return m, nil
 
 	case togglePauseMsg:
-		m.paused = !m.paused
-		m.blockerPaused.Store(m.paused)
-		if !m.paused && m.needsFastTick() {
-			m.lastTickAt = time.Now()
-			return m, doVizTick()
+		return m, m.togglePause()
+
+	case setPausedMsg:
+		if m.paused != msg.paused {
+			return m, m.togglePause()
 		}
 		return m, nil
 
+	case adjustTimeMsg:
+		m.adjustTime(msg.delta)
+		return m, nil
+
+	case setTaskMsg:
+		m.taskName = msg.name
+		return m, nil
+
+	case signalMsg:
+		return m, m.handleSignal(msg.sig)
+
+	case quitMsg:
+		m.hungUp = msg.hangup
+		m.done = true
+		m.shutdown()
+		return m, tea.Quit
+
+	case statusQueryMsg:
+		msg.reply <- m.status()
+		return m, nil
+
 	case vizTickMsg:
 		if m.paused || m.done {
 			return m, nil
@@ -155,11 +327,38 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, doVizTick()
 
 	case tickMsg:
-		if m.paused || m.done {
-			return m, doTick()
+		if m.done {
+			return m, nil
+		}
+		now := m.clock.Now()
+		gap := m.suspendedSince(now)
+		m.syncWatchdog(now)
+		for _, err := range m.hooks.takeErrors() {
+			m.setNotice(err.Error())
+		}
+		if m.paused {
+			if m.strict.enabled() && m.strict.pauseLimit > 0 && now.Sub(m.pausedAt) >= m.strict.pauseLimit {
+				m.setNotice(fmt.Sprintf("pause limit of %s reached, back to work", formatDuration(m.strict.pauseLimit)))
+				return m, tea.Batch(m.togglePause(), doTick(m.tickAlign(now)))
+			}
+			return m, doTick(m.tickAlign(now))
+		}
+
+		prev := m.remaining
+		m.remaining = ceilSecond(m.remainingAt(now))
+		if m.phase == phaseWork && prev > m.remaining {
+			m.focused += prev - m.remaining
+		}
+		if len(m.blockers) > 0 {
+			m.noticeBlockers()
+		}
+		if gap > 0 {
+			// The deadline is real time, so time spent asleep still counts
+			// against the session; it just isn't counted as focused.
+			m.deadline = m.deadline.Add(-gap)
+			m.remaining = ceilSecond(m.remainingAt(now))
+			m.setNotice(fmt.Sprintf("system was suspended for %s", formatDuration(gap)))
 		}
-		m.remaining -= time.Second
-		m.lastTickAt = time.Now()
 		if m.vizMode == "binary" {
 			m.updateBinaryFade()
 		}
@@ -174,28 +373,251 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.initSortGrid()
 		}
 		if m.remaining <= 0 {
+			if m.phase == phaseWork {
+				m.cyclesDone++
+			}
+			if m.pomodoro.enabled() {
+				if next, cycle, ok := m.pomodoro.next(m.phase, m.cycle); ok {
+					m.startPhase(next, cycle)
+					return m, doTick(m.tickAlign(now))
+				}
+			}
 			m.remaining = 0
 			m.done = true
 			m.shutdown()
 			return m, tea.Quit
 		}
-		return m, doTick()
-
-	case blockerStoppedMsg:
-		return m, nil
+		return m, doTick(m.tickAlign(now))
 	}
 
 	return m, nil
 }
 
+// syncWatchdog tells the watchdog when the session now ends.
+func (m *model) syncWatchdog(now time.Time) {
+	if m.watchdog == nil {
+		return
+	}
+	left, later := m.schedule(now)
+	m.watchdog.update(m.paused, left, later, m.phase, m.cycle, time.Now())
+}
+
+// schedule returns the time left in the current phase and how long the
+// pomodoro phases after it take.
+func (m model) schedule(now time.Time) (left, later time.Duration) {
+	if m.pomodoro.enabled() {
+		for ph, cycle, ok := m.pomodoro.next(m.phase, m.cycle); ok; ph, cycle, ok = m.pomodoro.next(ph, cycle) {
+			later += m.pomodoro.duration(ph)
+		}
+	}
+	return m.remainingAt(now), later
+}
+
+func (m *model) togglePause() tea.Cmd {
+	if !m.paused && m.strict.enabled() && m.pauses >= m.strict.pauses {
+		m.setNotice("no pauses left in this strict session")
+		return nil
+	}
+	now := m.clock.Now()
+	m.paused = !m.paused
+	if m.paused {
+		m.pauses++
+		m.pausedAt = now
+	} else {
+		pausedFor := now.Sub(m.pausedAt)
+		m.deadline = m.deadline.Add(pausedFor)
+		m.pausedTotal += pausedFor
+	}
+	m.syncBlocker()
+	if m.paused {
+		m.runHook("pause")
+	} else {
+		m.runHook("resume")
+	}
+	if !m.paused && m.needsFastTick() {
+		return doVizTick()
+	}
+	return nil
+}
+
+func (m *model) setNotice(text string) {
+	m.notice = text
+	m.noticeAt = m.clock.Now()
+}
+
+// adjustTime adds delta to the current phase. Subtracting more than is
+// left ends the phase on the next tick.
+func (m *model) adjustTime(delta time.Duration) {
+	current := m.remainingAt(m.clock.Now())
+	remaining := current + delta
+	if remaining < time.Second {
+		remaining = time.Second
+	}
+	m.deadline = m.deadline.Add(remaining - current)
+	m.totalDuration += remaining - current
+	if m.totalDuration < remaining {
+		m.totalDuration = remaining
+	}
+	m.remaining = ceilSecond(remaining)
+}
+
+func (m model) status() sessionStatus {
+	st := sessionStatus{
+		PID:          os.Getpid(),
+		Task:         m.taskName,
+		RemainingSec: int64(ceilSecond(m.remainingAt(m.clock.Now())) / time.Second),
+		TotalSec:     int64(m.totalDuration / time.Second),
+		Paused:       m.paused,
+		Done:         m.done,
+		Strict:       m.strict.enabled(),
+	}
+	if !m.until.IsZero() {
+		st.Until = m.until.Format(time.RFC3339)
+	}
+	if m.pomodoro.enabled() {
+		st.Phase = m.phase.String()
+		st.Cycle = m.cycle
+		st.Cycles = m.pomodoro.cycles
+	}
+	return st
+}
+
+// startPhase switches to the next pomodoro phase and resets the
+// per-phase viz state so each phase animates from the beginning.
+func (m *model) startPhase(ph phase, cycle int) {
+	m.phase = ph
+	m.cycle = cycle
+	m.totalDuration = m.pomodoro.duration(ph)
+	m.remaining = m.totalDuration
+	m.deadline = m.clock.Now().Add(m.totalDuration)
+
+	m.barPrevFilled = 0
+	m.barSliceAt = time.Time{}
+	m.binaryPrevBits = nil
+	m.defragOriginal = nil
+	m.defragWidth = 0
+	m.sortFrames = nil
+	m.sortWidth = 0
+	if m.width > 0 {
+		if m.vizMode == "defrag" {
+			m.initDefragGrid()
+		}
+		if m.vizMode == "bubble" || m.vizMode == "merge" || m.vizMode == "quick" {
+			m.initSortGrid()
+		}
+	}
+	if m.vizMode == "binary" {
+		m.updateBinaryFade()
+	}
+
+	m.syncBlocker()
+}
+
+// syncBlocker pauses the blockers while paused and during break phases.
+// Blockers with break rules switch to them for breaks instead.
+func (m *model) syncBlocker() {
+	paused, onBreak := m.paused, m.phase != phaseWork
+	syncBlockers(m.blockers, m.blockPaused, m.blockBreak, paused, onBreak)
+	m.blockPaused, m.blockBreak = paused, onBreak
+}
+
+// syncBlockers moves blockers last synced as wasPaused and wasBreak to
+// paused and onBreak.
+func syncBlockers(blockers []Blocker, wasPaused, wasBreak, paused, onBreak bool) {
+	if paused == wasPaused && onBreak == wasBreak {
+		return
+	}
+	for _, b := range blockers {
+		pb, switches := b.(phaseBlocker)
+		wasIdle, idle := wasPaused || wasBreak && !switches, paused || onBreak && !switches
+		if switches && onBreak != wasBreak {
+			pb.SetBreak(onBreak)
+		}
+		switch {
+		case idle && !wasIdle:
+			b.Pause()
+		case !idle && wasIdle:
+			b.Resume()
+		}
+	}
+}
+
+// shutdown ends the session, completed or not. It runs once. The blockers
+// are stopped by main and the MCP server once the program returns, since a
+// command blocker's stop can take a while and would freeze the UI.
 func (m *model) shutdown() {
-	select {
-	case <-m.blockerStop:
-	default:
-		close(m.blockerStop)
+	if m.ended {
+		return
+	}
+	m.ended = true
+	if m.remaining <= 0 {
+		m.runHook("complete")
+	} else {
+		m.runHook("abort")
 	}
 }
 
+// noticeBlockers refreshes the blockers' reports and surfaces the newest
+// thing one of them did, or an error it hasn't shown yet.
+func (m *model) noticeBlockers() {
+	m.reports = nil
+	for _, b := range m.blockers {
+		r := b.Report()
+		m.reports = append(m.reports, r)
+
+		errText := ""
+		if r.err != nil {
+			errText = r.err.Error()
+		}
+		if errText != "" && errText != m.blockErrs[b.Name()] {
+			m.setNotice(fmt.Sprintf("%s blocker: %s", b.Name(), errText))
+		}
+		m.blockErrs[b.Name()] = errText
+		if r.notice != "" && r.noticeAt.After(m.lastNoticeAt) {
+			m.lastNoticeAt = r.noticeAt
+			m.setNotice(r.notice)
+		}
+	}
+}
+
+// record summarizes the session for the history file.
+func (m model) record(end time.Time) sessionRecord {
+	rec := sessionRecord{
+		Start:      m.startedAt,
+		End:        end,
+		PlannedSec: int64(m.totalDuration / time.Second),
+		ActualSec:  int64(m.focused / time.Second),
+		Task:       m.taskName,
+		Pauses:     m.pauses,
+		PausedSec:  int64(m.pausedTotal / time.Second),
+		Blocked:    m.blockApps,
+		BreakBlock: m.breakBlock,
+		Allowed:    m.allowApps,
+		Sites:      m.blockSites,
+		Outcome:    outcomeQuit,
+	}
+	for _, b := range m.blockers {
+		for app, n := range b.Report().attempts {
+			if rec.Attempts == nil {
+				rec.Attempts = map[string]int{}
+			}
+			rec.Attempts[app] += n
+		}
+	}
+	if m.remaining <= 0 {
+		rec.Outcome = outcomeCompleted
+	} else if m.abandonReason != "" {
+		rec.Outcome = outcomeAbandoned
+		rec.Reason = m.abandonReason
+	}
+	if m.pomodoro.enabled() {
+		rec.PlannedSec = int64(m.pomodoro.work*time.Duration(m.pomodoro.cycles)) / int64(time.Second)
+		rec.Pomodoro = m.pomodoro.String()
+		rec.Cycles = m.cyclesDone
+	}
+	return rec
+}
+
 func (m model) View() string {
 	if m.done {
 		return ""
@@ -211,12 +633,60 @@ func (m model) View() string {
 		sections = append(sections, style.Render(m.taskName))
 	}
 
+	// Pomodoro phase and cycle
+	if m.pomodoro.enabled() {
+		color := colorGreen
+		if m.phase != phaseWork {
+			color = colorDefragData
+		}
+		style := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(color)
+		label := fmt.Sprintf("%s · %d/%d", strings.ToUpper(m.phase.String()), m.cycle, m.pomodoro.cycles)
+		sections = append(sections, style.Render(label))
+	}
+
 	// Spacer
 	sections = append(sections, "")
 
 	// Big timer digits
 	sections = append(sections, m.renderBigTimer())
 
+	// Target end time
+	if !m.until.IsZero() {
+		style := lipgloss.NewStyle().
+			Foreground(colorDim)
+		sections = append(sections, "")
+		sections = append(sections, style.Render("until "+m.until.Format("15:04")))
+	}
+
+	// Notice
+	if m.notice != "" && m.clock.Now().Sub(m.noticeAt) < noticeDuration {
+		style := lipgloss.NewStyle().
+			Foreground(colorYellow)
+		sections = append(sections, "")
+		sections = append(sections, style.Render(m.notice))
+	}
+
+	// Apps about to be closed
+	var warnings []string
+	for _, r := range m.reports {
+		warnings = append(warnings, r.warnings...)
+	}
+	if len(warnings) > 0 {
+		style := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(colorRed)
+		sections = append(sections, "")
+		sections = append(sections, style.Render("⚠ "+strings.Join(warnings, ", ")))
+	}
+
+	// Strict quit prompt
+	if m.quitting {
+		sections = append(sections, "")
+		sections = append(sections, m.renderQuitPrompt())
+	}
+
 	// Pause indicator
 	if m.paused {
 		style := lipgloss.NewStyle().
@@ -224,6 +694,11 @@ func (m model) View() string {
 			Foreground(lipgloss.Color("11"))
 		sections = append(sections, "")
 		sections = append(sections, style.Render("PAUSED"))
+		if m.strict.enabled() && m.strict.pauseLimit > 0 {
+			left := m.strict.pauseLimit - m.clock.Now().Sub(m.pausedAt)
+			dim := lipgloss.NewStyle().Foreground(colorDim)
+			sections = append(sections, dim.Render("resumes in "+formatDuration(ceilSecond(max(left, 0)))))
+		}
 	}
 
 	// Visualization
@@ -232,8 +707,8 @@ func (m model) View() string {
 		sections = append(sections, m.renderViz())
 	}
 
-	// Blocked apps
-	if len(m.blockApps) > 0 {
+	// Blockers
+	if len(m.blockers) > 0 {
 		sections = append(sections, "")
 		sections = append(sections, m.renderBlockedApps())
 	}
@@ -314,7 +789,7 @@ func (m *model) updateDotFade() {
 	if len(m.dotPrevCells) != total {
 		m.dotPrevCells = currentCells
 		m.dotOnAt = make([]time.Time, total)
-		now := time.Now()
+		now := m.clock.Now()
 		for i, on := range currentCells {
 			if on {
 				m.dotOnAt[i] = now
@@ -323,7 +798,7 @@ func (m *model) updateDotFade() {
 		return
 	}
 
-	now := time.Now()
+	now := m.clock.Now()
 	for i := range currentCells {
 		if !m.dotPrevCells[i] && currentCells[i] {
 			m.dotOnAt[i] = now
@@ -414,7 +889,7 @@ func (m model) renderBigTimer() string {
 				if isFilled {
 					color := gradientColor
 					if cellIdx < len(m.dotOnAt) && !m.dotOnAt[cellIdx].IsZero() {
-						elapsed := time.Since(m.dotOnAt[cellIdx])
+						elapsed := m.clock.Now().Sub(m.dotOnAt[cellIdx])
 						if elapsed < dotFlareDuration {
 							frac := float64(elapsed) / float64(dotFlareDuration)
 							color = modifyColor(gradientColor, func(c hsl) hsl {
@@ -436,8 +911,8 @@ func (m model) renderBigTimer() string {
 
 func (m model) renderBlockedApps() string {
 	var parts []string
-	for _, app := range m.blockApps {
-		parts = append(parts, "🔒 "+app)
+	for _, r := range m.reports {
+		parts = append(parts, r.labels...)
 	}
 	style := lipgloss.NewStyle().
 		Foreground(colorDim)
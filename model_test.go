@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestMain(m *testing.M) {
+	// Plain text, so the golden files don't depend on the terminal
+	lipgloss.SetColorProfile(termenv.Ascii)
+	os.Exit(m.Run())
+}
+
+// fakeClock is a clock the test moves by hand. Its Now has no monotonic
+// reading, so it stands in for the monotonic clock, and boot for
+// CLOCK_BOOTTIME.
+type fakeClock struct {
+	now  time.Time
+	boot time.Duration
+}
+
+func (c *fakeClock) Now() time.Time           { return c.now }
+func (c *fakeClock) SinceBoot() time.Duration { return c.boot }
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.boot += d
+}
+
+// suspend sleeps the system for d: only the time since boot moves.
+func (c *fakeClock) suspend(d time.Duration) { c.boot += d }
+
+// driveSession drives a 25 minute session, or cfg's pomodoro plan, through
+// its first 90 seconds, a second tick and ten viz ticks at a time.
+func driveSession(t *testing.T, cfg config) model {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	cfg.clock = clock
+	cfg.seed = 1
+	cfg.duration = 25 * time.Minute
+	cfg.taskName = "write the tests"
+	cfg.resolve()
+
+	var tm tea.Model = newModel(cfg)
+	tm.Init()
+	tm, _ = tm.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	for range 90 {
+		for range 10 {
+			clock.advance(100 * time.Millisecond)
+			tm, _ = tm.Update(vizTickMsg{})
+		}
+		tm, _ = tm.Update(tickMsg(clock.Now()))
+	}
+	return tm.(model)
+}
+
+func TestViewGolden(t *testing.T) {
+	for _, viz := range []string{"bar", "defrag", "binary", "bubble", "merge", "quick"} {
+		for _, font := range []string{"block", "slim", "dot"} {
+			name := viz + "_" + font
+			t.Run(name, func(t *testing.T) {
+				m := driveSession(t, config{vizMode: viz, fontStyle: font})
+				if got := m.remaining; got != 23*time.Minute+30*time.Second {
+					t.Fatalf("remaining = %s, want 23m30s", got)
+				}
+				checkGolden(t, name, m.View())
+			})
+		}
+	}
+}
+
+func TestViewPaused(t *testing.T) {
+	m := driveSession(t, config{vizMode: "bar", fontStyle: "block"})
+	tm, _ := m.Update(togglePauseMsg{})
+	checkGolden(t, "paused", tm.View())
+}
+
+func TestViewPomodoroBreak(t *testing.T) {
+	plan, err := parsePomodoro("1m/5m x2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := driveSession(t, config{vizMode: "bar", fontStyle: "slim", pomodoro: plan})
+	if m.phase != phaseShortBreak || m.cycle != 1 {
+		t.Fatalf("phase = %s of cycle %d, want short break of cycle 1", m.phase, m.cycle)
+	}
+	checkGolden(t, "pomodoro_break", m.View())
+}
+
+func TestViewDeterministic(t *testing.T) {
+	a := driveSession(t, config{vizMode: "defrag", fontStyle: "dot"}).View()
+	b := driveSession(t, config{vizMode: "defrag", fontStyle: "dot"}).View()
+	if a != b {
+		t.Error("the same clock and seed rendered two different views")
+	}
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("View() doesn't match %s (run go test -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestTickAlign(t *testing.T) {
+	strict := defaultStrict
+	strict.on = true
+	tests := []struct {
+		name   string
+		strict strictPlan
+		pause  bool
+		want   time.Duration
+	}{
+		{"running", strictPlan{}, false, 24*time.Minute + 57*time.Second + 700*time.Millisecond},
+		{"paused", strictPlan{}, true, time.Second},
+		{"paused with a limit", strict, true, 4*time.Minute + 57*time.Second + 700*time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+			cfg := config{clock: clock, duration: 25 * time.Minute, strict: tt.strict}
+			cfg.resolve()
+			var tm tea.Model = newModel(cfg)
+			if tt.pause {
+				tm, _ = tm.Update(togglePauseMsg{})
+			}
+			clock.advance(2300 * time.Millisecond)
+			tm, _ = tm.Update(tickMsg(clock.Now()))
+			if got := tm.(model).tickAlign(clock.Now()); got != tt.want {
+				t.Errorf("tickAlign = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuspendGap(t *testing.T) {
+	tests := []struct {
+		name           string
+		elapsed, awake time.Duration
+		want           time.Duration
+	}{
+		{"no suspend", time.Second, time.Second, 0},
+		{"late tick", 3 * time.Second, 3 * time.Second, 0},
+		{"clock jitter", 4 * time.Second, time.Second, 0},
+		{"suspend", 10*time.Minute + time.Second, time.Second, 10 * time.Minute},
+		// The wall clock can't tell an NTP step forward from a suspend;
+		// CLOCK_BOOTTIME doesn't move for one
+		{"wall clock stepped forward", 10*time.Minute + time.Second, time.Second, 10 * time.Minute},
+		{"wall clock stepped back", time.Second, 10 * time.Minute, 0},
+	}
+	for _, tt := range tests {
+		if got := suspendGap(tt.elapsed, tt.awake); got != tt.want {
+			t.Errorf("%s: suspendGap(%s, %s) = %s, want %s", tt.name, tt.elapsed, tt.awake, got, tt.want)
+		}
+	}
+}
+
+func TestSuspendShiftsDeadline(t *testing.T) {
+	tests := []struct {
+		name      string
+		sleep     time.Duration
+		remaining time.Duration
+		notice    string
+	}{
+		{"suspend", 10 * time.Minute, 14*time.Minute + 57*time.Second, "system was suspended for 10m"},
+		{"short suspend", 3 * time.Second, 24*time.Minute + 57*time.Second, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), boot: time.Hour}
+			cfg := config{clock: clock, duration: 25 * time.Minute}
+			cfg.resolve()
+			var tm tea.Model = newModel(cfg)
+			for range 2 {
+				clock.advance(time.Second)
+				tm, _ = tm.Update(tickMsg(clock.Now()))
+			}
+			clock.suspend(tt.sleep)
+			clock.advance(time.Second)
+			tm, _ = tm.Update(tickMsg(clock.Now()))
+
+			m := tm.(model)
+			if m.remaining != tt.remaining {
+				t.Errorf("remaining = %s, want %s", m.remaining, tt.remaining)
+			}
+			if m.focused != 3*time.Second {
+				t.Errorf("focused = %s, want 3s: time asleep isn't focus", m.focused)
+			}
+			if m.notice != tt.notice {
+				t.Errorf("notice = %q, want %q", m.notice, tt.notice)
+			}
+		})
+	}
+}
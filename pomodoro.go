@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type phase int
+
+const (
+	phaseWork phase = iota
+	phaseShortBreak
+	phaseLongBreak
+)
+
+func (p phase) String() string {
+	switch p {
+	case phaseShortBreak:
+		return "short break"
+	case phaseLongBreak:
+		return "long break"
+	default:
+		return "work"
+	}
+}
+
+// pomodoroPlan describes a work/break cycle. A zero plan means pomodoro
+// mode is off and the session is a single timer.
+type pomodoroPlan struct {
+	work       time.Duration
+	shortBreak time.Duration
+	longBreak  time.Duration
+	cycles     int
+}
+
+func (p pomodoroPlan) enabled() bool { return p.cycles > 0 }
+
+func (p pomodoroPlan) duration(ph phase) time.Duration {
+	switch ph {
+	case phaseShortBreak:
+		return p.shortBreak
+	case phaseLongBreak:
+		return p.longBreak
+	default:
+		return p.work
+	}
+}
+
+// next returns the phase following ph. Every work phase is followed by a
+// short break except the last, which gets the long break; the plan is
+// finished once the long break ends.
+func (p pomodoroPlan) next(ph phase, cycle int) (phase, int, bool) {
+	switch ph {
+	case phaseWork:
+		if cycle >= p.cycles {
+			return phaseLongBreak, cycle, true
+		}
+		return phaseShortBreak, cycle, true
+	case phaseShortBreak:
+		return phaseWork, cycle + 1, true
+	default:
+		return ph, cycle, false
+	}
+}
+
+func (p pomodoroPlan) String() string {
+	return fmt.Sprintf("%s/%s/%s x%d", p.work, p.shortBreak, p.longBreak, p.cycles)
+}
+
+// parsePomodoro parses "work/short/long xN", e.g. "25m/5m/15m x4".
+// The long break defaults to the short break and the cycle count to 4.
+func parsePomodoro(s string) (pomodoroPlan, error) {
+	plan := pomodoroPlan{cycles: 4}
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return plan, fmt.Errorf("invalid pomodoro %q (use work/short/long xN, e.g. 25m/5m/15m x4)", s)
+	}
+	if len(fields) == 2 {
+		n, ok := parseCycles(fields[1])
+		if !ok {
+			return plan, fmt.Errorf("invalid cycle count %q (use xN, e.g. x4)", fields[1])
+		}
+		plan.cycles = n
+	}
+
+	parts := strings.Split(fields[0], "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return plan, fmt.Errorf("invalid pomodoro %q (use work/short/long xN, e.g. 25m/5m/15m x4)", s)
+	}
+	var ds []time.Duration
+	for _, part := range parts {
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return plan, fmt.Errorf("invalid duration %q: %v", part, err)
+		}
+		if d <= 0 {
+			return plan, fmt.Errorf("pomodoro durations must be positive")
+		}
+		ds = append(ds, d)
+	}
+	plan.work = ds[0]
+	plan.shortBreak = ds[1]
+	plan.longBreak = ds[1]
+	if len(ds) == 3 {
+		plan.longBreak = ds[2]
+	}
+	return plan, nil
+}
+
+// parseCycles parses a cycle count of the form "x4".
+func parseCycles(s string) (int, bool) {
+	if !strings.HasPrefix(s, "x") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[1:])
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePomodoro(t *testing.T) {
+	tests := []struct {
+		in   string
+		want pomodoroPlan
+	}{
+		{"25m/5m/15m x4", pomodoroPlan{25 * time.Minute, 5 * time.Minute, 15 * time.Minute, 4}},
+		{"50m/10m", pomodoroPlan{50 * time.Minute, 10 * time.Minute, 10 * time.Minute, 4}},
+		{"25m/5m x2", pomodoroPlan{25 * time.Minute, 5 * time.Minute, 5 * time.Minute, 2}},
+		{" 1h/10m/30m  x1 ", pomodoroPlan{time.Hour, 10 * time.Minute, 30 * time.Minute, 1}},
+	}
+	for _, tt := range tests {
+		got, err := parsePomodoro(tt.in)
+		if err != nil {
+			t.Errorf("parsePomodoro(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePomodoro(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "25m", "25m/5m/15m/5m", "25m/0s", "25m/-5m", "25m/five", "25m/5m x0", "25m/5m 4", "25m/5m x4 x4"} {
+		if got, err := parsePomodoro(in); err == nil {
+			t.Errorf("parsePomodoro(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestPomodoroNext(t *testing.T) {
+	plan := pomodoroPlan{work: time.Minute, shortBreak: time.Minute, longBreak: time.Minute, cycles: 2}
+	type step struct {
+		ph    phase
+		cycle int
+	}
+	want := []step{{phaseWork, 1}, {phaseShortBreak, 1}, {phaseWork, 2}, {phaseLongBreak, 2}}
+
+	got := []step{{phaseWork, 1}}
+	for ph, cycle, ok := plan.next(phaseWork, 1); ok; ph, cycle, ok = plan.next(ph, cycle) {
+		got = append(got, step{ph, cycle})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("phases = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("phase %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import "path/filepath"
+
+// process is one running process as seen by the blocker.
+type process struct {
+	pid     int
+	ppid    int
+	uid     int
+	tty     bool   // has a controlling terminal
+	comm    string // kernel process name, truncated to 15 bytes on Linux
+	exe     string // executable path, empty if unreadable
+	cmdline []string
+}
+
+// displayName prefers the executable's name, which isn't truncated.
+func (p process) displayName() string {
+	if p.exe != "" {
+		return filepath.Base(p.exe)
+	}
+	return p.comm
+}
+
+// names returns every name the process goes by, for pattern matching.
+func (p process) names() []string {
+	names := []string{p.comm}
+	if p.exe != "" {
+		names = append(names, filepath.Base(p.exe))
+	}
+	if len(p.cmdline) > 0 && p.cmdline[0] != "" {
+		names = append(names, filepath.Base(p.cmdline[0]))
+	}
+	return names
+}
+
+// ancestors returns pid and every process above it in procs, so the
+// blocker never closes the shell or wrapper that started lockin.
+func ancestors(procs []process, pid int) map[int]bool {
+	parent := make(map[int]int, len(procs))
+	for _, p := range procs {
+		parent[p.pid] = p.ppid
+	}
+	seen := map[int]bool{}
+	for pid > 1 && !seen[pid] {
+		seen[pid] = true
+		pid = parent[pid]
+	}
+	return seen
+}
+
+// descendants returns pid and every process below it in procs, skipping
+// the pids in except.
+func descendants(procs []process, pid int, except map[int]bool) []int {
+	children := map[int][]int{}
+	for _, p := range procs {
+		children[p.ppid] = append(children[p.ppid], p.pid)
+	}
+	var tree []int
+	seen := map[int]bool{}
+	queue := []int{pid}
+	for len(queue) > 0 {
+		pid, queue = queue[0], queue[1:]
+		if seen[pid] || except[pid] {
+			continue
+		}
+		seen[pid] = true
+		tree = append(tree, pid)
+		queue = append(queue, children[pid]...)
+	}
+	return tree
+}
+
+// commLen is the longest process name the Linux kernel keeps.
+const commLen = 15
+
+// hasName reports whether the process is called name, the way pkill -x
+// would match it but without being fooled by comm truncation.
+func (p process) hasName(name string) bool {
+	if p.comm == name {
+		return true
+	}
+	if len(name) > commLen && p.comm == name[:commLen] {
+		return true
+	}
+	if p.exe != "" && filepath.Base(p.exe) == name {
+		return true
+	}
+	return len(p.cmdline) > 0 && filepath.Base(p.cmdline[0]) == name
+}
@@ -0,0 +1,63 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listProcesses reads every process from /proc. Processes that exit
+// mid-scan are skipped; exe is empty for processes we can't inspect.
+func listProcesses() ([]process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var procs []process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		if p, ok := readProcess(pid); ok {
+			procs = append(procs, p)
+		}
+	}
+	return procs, nil
+}
+
+func readProcess(pid int) (process, bool) {
+	dir := "/proc/" + strconv.Itoa(pid)
+	comm, err := os.ReadFile(dir + "/comm")
+	if err != nil {
+		return process{}, false
+	}
+	p := process{pid: pid, comm: strings.TrimSuffix(string(comm), "\n")}
+	if stat, err := os.ReadFile(dir + "/stat"); err == nil {
+		// Fields after the parenthesised comm: state ppid pgrp session tty_nr ...
+		if i := strings.LastIndexByte(string(stat), ')'); i >= 0 {
+			if f := strings.Fields(string(stat[i+1:])); len(f) > 4 {
+				if f[0] == "Z" {
+					// Exited and waiting to be reaped, so nothing to block
+					return process{}, false
+				}
+				p.ppid, _ = strconv.Atoi(f[1])
+				p.tty = f[4] != "0"
+			}
+		}
+	}
+	var st syscall.Stat_t
+	if err := syscall.Stat(dir, &st); err == nil {
+		p.uid = int(st.Uid)
+	}
+	if exe, err := os.Readlink(dir + "/exe"); err == nil {
+		p.exe = strings.TrimSuffix(exe, " (deleted)")
+	}
+	if raw, err := os.ReadFile(dir + "/cmdline"); err == nil && len(raw) > 0 {
+		p.cmdline = strings.Split(strings.TrimRight(string(raw), "\x00"), "\x00")
+	}
+	return p, true
+}
@@ -0,0 +1,89 @@
+//go:build !linux
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// listProcesses asks ps for every process, since there is no /proc to
+// read. comm is the full executable path on macOS.
+func listProcesses() ([]process, error) {
+	// comm goes last because it can contain spaces
+	out, err := exec.Command("ps", "-axww", "-o", "pid=,ppid=,uid=,stat=,tty=,comm=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var procs []process
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) < 6 {
+			continue
+		}
+		pid, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(f[3], "Z") {
+			continue // exited, waiting to be reaped
+		}
+		comm := strings.Join(f[5:], " ")
+		p := process{pid: pid, comm: filepath.Base(comm)}
+		p.ppid, _ = strconv.Atoi(f[1])
+		p.uid, _ = strconv.Atoi(f[2])
+		p.tty = f[4] != "?" && f[4] != "??"
+		if filepath.IsAbs(comm) {
+			p.exe = comm
+		}
+		procs = append(procs, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	args, err := psArgs()
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range procs {
+		// A pid reused between the two runs has a different parent
+		if a, ok := args[p.pid]; ok && a.ppid == p.ppid {
+			procs[i].cmdline = a.args
+		}
+	}
+	return procs, nil
+}
+
+type psArgLine struct {
+	ppid int
+	args []string
+}
+
+// psArgs returns every process's arguments keyed by pid.
+func psArgs() (map[int]psArgLine, error) {
+	out, err := exec.Command("ps", "-axww", "-o", "pid=,ppid=,args=").Output()
+	if err != nil {
+		return nil, err
+	}
+	lines := map[int]psArgLine{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) < 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue
+		}
+		ppid, _ := strconv.Atoi(f[1])
+		lines[pid] = psArgLine{ppid: ppid, args: f[2:]}
+	}
+	return lines, scanner.Err()
+}
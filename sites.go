@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultHostsFile = "/etc/hosts"
+
+// The hosts file sections lockin owns. Each session's section is marked
+// with its pid and rewritten or removed as a unit, so sessions running
+// side by side leave each other's alone and a crashed session's entries
+// are cleaned up by the next run.
+const (
+	hostsBegin = "# BEGIN lockin"
+	hostsNote  = "blocked sites, removed when the session ends"
+	hostsEnd   = "# END lockin"
+)
+
+// parseSite checks a --block-sites entry is a bare host name.
+func parseSite(s string) (string, error) {
+	site := strings.ToLower(strings.TrimSpace(s))
+	if site == "" || strings.HasPrefix(site, ".") || strings.HasSuffix(site, ".") {
+		return "", fmt.Errorf("invalid site %q (use a host name like reddit.com)", s)
+	}
+	for _, r := range site {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '-') {
+			return "", fmt.Errorf("invalid site %q (use a host name like reddit.com)", s)
+		}
+	}
+	return site, nil
+}
+
+// hostsBlocker points blocked sites at an unroutable address through a
+// delimited section of the hosts file.
+type hostsBlocker struct {
+	path  string
+	sites []string
+	owner int // pid the section is marked with
+
+	mu     sync.Mutex
+	active bool  // section currently written
+	err    error // last failed update
+}
+
+func newHostsBlocker(path string, sites []string) *hostsBlocker {
+	if path == "" {
+		path = defaultHostsFile
+	}
+	return &hostsBlocker{path: path, sites: sites, owner: os.Getpid()}
+}
+
+func (h *hostsBlocker) Name() string { return "hosts" }
+
+// Start removes sections left behind by sessions that are no longer
+// running and writes this session's. It does nothing if there's nothing
+// to change.
+func (h *hostsBlocker) Start() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, os.ErrNotExist) && len(h.sites) == 0 {
+		return nil
+	}
+	if err == nil && len(h.sites) == 0 && !bytes.Contains(data, []byte(hostsBegin)) {
+		return nil
+	}
+	if err == nil {
+		err = h.write(data, len(h.sites) > 0)
+	}
+	if err != nil {
+		return fmt.Errorf("could not update %s: %v", h.path, err)
+	}
+	return nil
+}
+
+// Pause removes the section until Resume restores it.
+func (h *hostsBlocker) Pause()  { h.setActive(false) }
+func (h *hostsBlocker) Resume() { h.setActive(true) }
+
+// Stop removes this session's section. A failure is reported and
+// retried on the next call.
+func (h *hostsBlocker) Stop() { h.setActive(false) }
+
+func (h *hostsBlocker) setActive(active bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.sites) == 0 || h.active == active {
+		return
+	}
+	h.err = h.rewrite(active)
+}
+
+func (h *hostsBlocker) Report() blockerReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var r blockerReport
+	for _, site := range h.sites {
+		r.labels = append(r.labels, "🌐 "+site)
+	}
+	if h.err != nil {
+		r.err = fmt.Errorf("could not update %s: %v", h.path, h.err)
+	}
+	return r
+}
+
+func (h *hostsBlocker) rewrite(active bool) error {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		return err
+	}
+	return h.write(data, active)
+}
+
+// write replaces this session's section in data, drops those of dead
+// sessions, and saves the file in place, keeping its permissions and any
+// hard or bind mounts intact.
+func (h *hostsBlocker) write(data []byte, active bool) error {
+	out := stripHostsSection(string(data), func(owner int) bool {
+		return owner == h.owner || !alive(owner)
+	})
+	if active {
+		if out != "" && !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		out += hostsSection(h.owner, h.sites)
+	}
+	if out == string(data) {
+		// Nothing to change, and the file may not be ours to write
+		h.active = active
+		return nil
+	}
+	if err := os.WriteFile(h.path, []byte(out), 0o644); err != nil {
+		return err
+	}
+	h.active = active
+	return nil
+}
+
+func hostsSection(owner int, sites []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d: %s\n", hostsBegin, owner, hostsNote)
+	for _, site := range sites {
+		hosts := site
+		if !strings.HasPrefix(site, "www.") {
+			hosts += " www." + site
+		}
+		fmt.Fprintf(&b, "0.0.0.0 %s\n:: %s\n", hosts, hosts)
+	}
+	b.WriteString(hostsEnd + "\n")
+	return b.String()
+}
+
+// stripHostsSection returns data without the lockin sections whose owner
+// drop picks. A section without a pid has owner 0. An unterminated
+// section runs to the end of the file.
+func stripHostsSection(data string, drop func(owner int) bool) string {
+	var out []string
+	inside, dropping := false, false
+	for _, line := range strings.SplitAfter(data, "\n") {
+		if owner, ok := hostsOwner(line); ok && !inside {
+			inside, dropping = true, drop(owner)
+		}
+		if !inside || !dropping {
+			out = append(out, line)
+		}
+		if inside && strings.TrimSpace(line) == hostsEnd {
+			inside = false
+		}
+	}
+	return strings.Join(out, "")
+}
+
+// hostsOwner parses a section's begin marker, "# BEGIN lockin 1234: ...".
+func hostsOwner(line string) (int, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), hostsBegin)
+	if !ok || rest != "" && rest[0] != ' ' && rest[0] != ':' {
+		return 0, false
+	}
+	pid, _, _ := strings.Cut(strings.TrimSpace(rest), ":")
+	owner, _ := strconv.Atoi(pid)
+	return owner, true
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripHostsSection(t *testing.T) {
+	live := hostsSection(101, []string{"reddit.com"})
+	dead := hostsSection(202, []string{"news.ycombinator.com"})
+	legacy := "# BEGIN lockin: blocked sites, removed when the session ends\n0.0.0.0 old.com\n# END lockin\n"
+	data := "127.0.0.1 localhost\n" + live + "::1 localhost\n" + dead + legacy
+
+	got := stripHostsSection(data, func(owner int) bool { return owner != 101 })
+	if want := "127.0.0.1 localhost\n" + live + "::1 localhost\n"; got != want {
+		t.Errorf("stripping other owners:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	got = stripHostsSection(data, func(owner int) bool { return true })
+	if want := "127.0.0.1 localhost\n::1 localhost\n"; got != want {
+		t.Errorf("stripping every section:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// An unterminated section runs to the end of the file
+	got = stripHostsSection("127.0.0.1 localhost\n# BEGIN lockin 7: x\n0.0.0.0 a.com\n", func(int) bool { return true })
+	if want := "127.0.0.1 localhost\n"; got != want {
+		t.Errorf("unterminated section: got %q, want %q", got, want)
+	}
+	// Without a trailing newline the last line is kept as is
+	if got := stripHostsSection("127.0.0.1 localhost", func(int) bool { return true }); got != "127.0.0.1 localhost" {
+		t.Errorf("no sections: got %q", got)
+	}
+}
+
+func TestHostsOwner(t *testing.T) {
+	tests := []struct {
+		line  string
+		owner int
+		ok    bool
+	}{
+		{"# BEGIN lockin 1234: blocked sites, removed when the session ends\n", 1234, true},
+		{"# BEGIN lockin: blocked sites, removed when the session ends", 0, true},
+		{"  # BEGIN lockin 9: x", 9, true},
+		{"# BEGIN lockinator", 0, false},
+		{"# END lockin", 0, false},
+		{"127.0.0.1 localhost", 0, false},
+	}
+	for _, tt := range tests {
+		owner, ok := hostsOwner(tt.line)
+		if owner != tt.owner || ok != tt.ok {
+			t.Errorf("hostsOwner(%q) = %d, %v, want %d, %v", tt.line, owner, ok, tt.owner, tt.ok)
+		}
+	}
+}
+
+func TestHostsBlockerKeepsOtherSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	// pid 1 is always running
+	other := hostsSection(1, []string{"example.org"})
+	if err := os.WriteFile(path, []byte("127.0.0.1 localhost\n"+other), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHostsBlocker(path, []string{"reddit.com"})
+	if err := h.Start(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), other) || !strings.Contains(string(data), "0.0.0.0 reddit.com www.reddit.com\n") {
+		t.Errorf("after Start:\n%s", data)
+	}
+
+	h.Stop()
+	data, _ = os.ReadFile(path)
+	if want := "127.0.0.1 localhost\n" + other; string(data) != want {
+		t.Errorf("after Stop:\ngot:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestParseSite(t *testing.T) {
+	for in, want := range map[string]string{"reddit.com": "reddit.com", " News.YCombinator.com ": "news.ycombinator.com", "my-site.co.uk": "my-site.co.uk"} {
+		if got, err := parseSite(in); err != nil || got != want {
+			t.Errorf("parseSite(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", ".reddit.com", "reddit.com.", "https://reddit.com", "reddit.com/r/golang", "red dit.com"} {
+		if _, err := parseSite(in); err == nil {
+			t.Errorf("parseSite(%q) succeeded, want an error", in)
+		}
+	}
+}
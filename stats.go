@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// dayStats aggregates the history by local calendar day.
+type dayStats struct {
+	focused   map[string]time.Duration // keyed by YYYY-MM-DD
+	completed int
+	quit      int
+	abandoned int            // strict sessions left early
+	attempts  map[string]int // blocked-app sightings by rule
+}
+
+const dayKey = "2006-01-02"
+
+func aggregateDays(records []sessionRecord) dayStats {
+	st := dayStats{focused: map[string]time.Duration{}, attempts: map[string]int{}}
+	for _, rec := range records {
+		st.focused[rec.Start.Local().Format(dayKey)] += rec.actual()
+		for app, n := range rec.Attempts {
+			st.attempts[app] += n
+		}
+		switch rec.Outcome {
+		case outcomeCompleted:
+			st.completed++
+		case outcomeAbandoned:
+			st.abandoned++
+		default:
+			st.quit++
+		}
+	}
+	return st
+}
+
+// sessions summarizes outcomes, e.g. "8 completed, 2 quit early (80%)".
+func (st dayStats) sessions(rate string) string {
+	s := fmt.Sprintf("%d completed, %d quit early", st.completed, st.quit)
+	if st.abandoned > 0 {
+		s += fmt.Sprintf(", %d abandoned", st.abandoned)
+	}
+	return s + " (" + rate + ")"
+}
+
+// topAttempts lists the n apps the blocker caught most often, e.g.
+// "Discord 14, Slack 6".
+func (st dayStats) topAttempts(n int) string {
+	apps := make([]string, 0, len(st.attempts))
+	for app := range st.attempts {
+		apps = append(apps, app)
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		if st.attempts[apps[i]] != st.attempts[apps[j]] {
+			return st.attempts[apps[i]] > st.attempts[apps[j]]
+		}
+		return apps[i] < apps[j]
+	})
+	if len(apps) > n {
+		apps = apps[:n]
+	}
+	var parts []string
+	for _, app := range apps {
+		parts = append(parts, fmt.Sprintf("%s %d", app, st.attempts[app]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// streaks returns the current run of focused days ending today (or
+// yesterday, if nothing has been logged yet today) and the longest run.
+func (st dayStats) streaks(today time.Time) (current, longest int) {
+	if len(st.focused) == 0 {
+		return 0, 0
+	}
+	first := today
+	for key := range st.focused {
+		if t, err := time.ParseInLocation(dayKey, key, today.Location()); err == nil && t.Before(first) {
+			first = t
+		}
+	}
+
+	run := 0
+	for d := first; !d.After(today); d = d.AddDate(0, 0, 1) {
+		if st.focused[d.Format(dayKey)] > 0 {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	d := today
+	if st.focused[d.Format(dayKey)] == 0 {
+		d = d.AddDate(0, 0, -1)
+	}
+	for st.focused[d.Format(dayKey)] > 0 {
+		current++
+		d = d.AddDate(0, 0, -1)
+	}
+	return current, longest
+}
+
+func runStats(args []string) {
+	var filter historyFilter
+	weeks := 20
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-h", "--help":
+			printStatsUsage()
+			os.Exit(0)
+		case "--weeks", "--task":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: %s requires an argument\n", args[i])
+				os.Exit(1)
+			}
+			i++
+			if args[i-1] == "--task" {
+				filter.task = args[i]
+				continue
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "error: invalid week count %q\n", args[i])
+				os.Exit(1)
+			}
+			weeks = n
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown argument %q\n", args[i])
+			printStatsUsage()
+			os.Exit(1)
+		}
+	}
+
+	records, err := readHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var selected []sessionRecord
+	for _, rec := range records {
+		if filter.match(rec) {
+			selected = append(selected, rec)
+		}
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	fmt.Println(renderStats(aggregateDays(selected), today, weeks))
+}
+
+func renderStats(st dayStats, today time.Time, weeks int) string {
+	labelStyle := lipgloss.NewStyle().Foreground(colorDim)
+	valueStyle := lipgloss.NewStyle().Bold(true)
+
+	var week time.Duration
+	for i := 0; i < 7; i++ {
+		week += st.focused[today.AddDate(0, 0, -i).Format(dayKey)]
+	}
+	current, longest := st.streaks(today)
+	rate := "—"
+	if total := st.completed + st.quit + st.abandoned; total > 0 {
+		rate = fmt.Sprintf("%d%%", st.completed*100/total)
+	}
+
+	summary := [][2]string{
+		{"Today", formatDuration(st.focused[today.Format(dayKey)])},
+		{"Last 7 days", formatDuration(week)},
+		{"Streak", fmt.Sprintf("%d days (longest %d)", current, longest)},
+		{"Sessions", st.sessions(rate)},
+	}
+	if top := st.topAttempts(3); top != "" {
+		summary = append(summary, [2]string{"Most blocked", top})
+	}
+	var lines []string
+	for _, kv := range summary {
+		lines = append(lines, labelStyle.Render(fmt.Sprintf("%-14s", kv[0]))+valueStyle.Render(kv[1]))
+	}
+
+	sections := []string{
+		strings.Join(lines, "\n"),
+		"",
+		renderDailyTotals(st, today),
+		"",
+		renderHeatmap(st, today, weeks),
+	}
+	return strings.Join(sections, "\n")
+}
+
+// renderDailyTotals draws one bar per day for the last week.
+func renderDailyTotals(st dayStats, today time.Time) string {
+	const barWidth = 30
+
+	var max time.Duration
+	for i := 0; i < 7; i++ {
+		if d := st.focused[today.AddDate(0, 0, -i).Format(dayKey)]; d > max {
+			max = d
+		}
+	}
+
+	dataStyle := lipgloss.NewStyle().Foreground(colorDefragData)
+	freeStyle := lipgloss.NewStyle().Foreground(colorDim)
+	labelStyle := lipgloss.NewStyle().Foreground(colorDim)
+
+	var rows []string
+	for i := 6; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		d := st.focused[day.Format(dayKey)]
+		filled := 0
+		if max > 0 {
+			filled = int(float64(d) / float64(max) * barWidth)
+		}
+		if d > 0 && filled == 0 {
+			filled = 1
+		}
+		bar := dataStyle.Render(strings.Repeat("█", filled)) +
+			freeStyle.Render(strings.Repeat("░", barWidth-filled))
+		rows = append(rows, labelStyle.Render(day.Format("Mon 01-02"))+"  "+bar+"  "+formatDuration(d))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// renderHeatmap draws a GitHub-style grid: one column per week, one row
+// per weekday, shaded by focused time relative to the busiest day.
+func renderHeatmap(st dayStats, today time.Time, weeks int) string {
+	// Align columns to weeks starting on Monday
+	offset := (int(today.Weekday()) + 6) % 7
+	start := today.AddDate(0, 0, -offset-7*(weeks-1))
+
+	var max time.Duration
+	for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+		if v := st.focused[d.Format(dayKey)]; v > max {
+			max = v
+		}
+	}
+
+	levels := make([]lipgloss.Style, 4)
+	for i := range levels {
+		l := 0.25 + 0.14*float64(i)
+		levels[i] = lipgloss.NewStyle().Foreground(modifyColor(colorDefragData, func(c hsl) hsl {
+			c.l = l
+			return c
+		}))
+	}
+	freeStyle := lipgloss.NewStyle().Foreground(colorDim)
+	labelStyle := lipgloss.NewStyle().Foreground(colorDim)
+
+	// Month labels above the first column of each month
+	header := []byte(strings.Repeat(" ", 4+2*weeks))
+	nextFree := 0
+	for w := 0; w < weeks; w++ {
+		col := start.AddDate(0, 0, 7*w)
+		pos := 4 + 2*w
+		if (w == 0 || col.AddDate(0, 0, -7).Month() != col.Month()) && pos >= nextFree && pos+3 <= len(header) {
+			copy(header[pos:], col.Format("Jan"))
+			nextFree = pos + 4
+		}
+	}
+
+	rows := []string{labelStyle.Render(string(header))}
+	dayLabels := []string{"Mon", "", "Wed", "", "Fri", "", "Sun"}
+	for wd := 0; wd < 7; wd++ {
+		var row strings.Builder
+		row.WriteString(labelStyle.Render(fmt.Sprintf("%-4s", dayLabels[wd])))
+		for w := 0; w < weeks; w++ {
+			day := start.AddDate(0, 0, 7*w+wd)
+			if day.After(today) {
+				row.WriteString("  ")
+				continue
+			}
+			v := st.focused[day.Format(dayKey)]
+			if v == 0 || max == 0 {
+				row.WriteString(freeStyle.Render("░░"))
+				continue
+			}
+			level := int(float64(v) / float64(max) * float64(len(levels)))
+			if level >= len(levels) {
+				level = len(levels) - 1
+			}
+			row.WriteString(levels[level].Render("██"))
+		}
+		rows = append(rows, row.String())
+	}
+	return strings.Join(rows, "\n")
+}
+
+func printStatsUsage() {
+	fmt.Fprintln(os.Stderr, `Usage: lockin stats [flags]
+
+Summarizes focused time from the history file.
+
+Flags:
+  --weeks N                Weeks shown in the heatmap (default 20)
+  --task TEXT              Only sessions whose task contains TEXT`)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreaks(t *testing.T) {
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name             string
+		days             []string // focused days
+		current, longest int
+	}{
+		{"nothing logged", nil, 0, 0},
+		{"today only", []string{"2026-03-10"}, 1, 1},
+		{"ending today", []string{"2026-03-08", "2026-03-09", "2026-03-10"}, 3, 3},
+		{"ending yesterday", []string{"2026-03-08", "2026-03-09"}, 2, 2},
+		{"ended two days ago", []string{"2026-03-07", "2026-03-08"}, 0, 2},
+		{"gap day", []string{"2026-03-04", "2026-03-05", "2026-03-06", "2026-03-08", "2026-03-09", "2026-03-10"}, 3, 3},
+		{"longer run before a gap", []string{"2026-03-01", "2026-03-02", "2026-03-03", "2026-03-04", "2026-03-06", "2026-03-07"}, 0, 4},
+		{"across a month", []string{"2026-02-27", "2026-02-28", "2026-03-01"}, 0, 3},
+	}
+	for _, tt := range tests {
+		st := dayStats{focused: map[string]time.Duration{}}
+		for _, day := range tt.days {
+			st.focused[day] = 25 * time.Minute
+		}
+		current, longest := st.streaks(today)
+		if current != tt.current || longest != tt.longest {
+			t.Errorf("%s: streaks = %d, %d, want %d, %d", tt.name, current, longest, tt.current, tt.longest)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// strictPlan makes a session hard to leave early: quitting takes a typed
+// phrase or a cooldown plus a reason, and pauses are rationed.
+type strictPlan struct {
+	on         bool
+	phrase     string
+	cooldown   time.Duration
+	pauses     int           // pauses allowed per session
+	pauseLimit time.Duration // longest pause before resuming on its own
+}
+
+var defaultStrict = strictPlan{
+	phrase:     "i am choosing to stop",
+	cooldown:   2 * time.Minute,
+	pauses:     1,
+	pauseLimit: 5 * time.Minute,
+}
+
+func (s strictPlan) enabled() bool { return s.on }
+
+// hangupReason is recorded when the terminal closes on a strict session.
+const hangupReason = "terminal closed"
+
+// strictSettings returns cfg's strict plan for a strict_* key to adjust,
+// starting from the defaults the first time.
+func (cfg *config) strictSettings() *strictPlan {
+	if cfg.strict.phrase == "" {
+		on := cfg.strict.on
+		cfg.strict = defaultStrict
+		cfg.strict.on = on
+	}
+	return &cfg.strict
+}
+
+// signalMsg carries a signal that strict mode intercepts instead of
+// letting it end the program.
+type signalMsg struct{ sig os.Signal }
+
+func listenStrictSignals(p *tea.Program) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for s := range sig {
+		p.Send(signalMsg{sig: s})
+	}
+}
+
+func (m *model) handleSignal(sig os.Signal) tea.Cmd {
+	if sig == syscall.SIGHUP {
+		// The terminal is gone, so nobody can be asked for a reason
+		m.hungUp = true
+		m.abandon(hangupReason)
+		return tea.Quit
+	}
+	m.setNotice(fmt.Sprintf("ignored %s: strict session, press q to quit", signalName(sig)))
+	return nil
+}
+
+func signalName(sig os.Signal) string {
+	switch sig {
+	case syscall.SIGINT:
+		return "SIGINT"
+	case syscall.SIGTERM:
+		return "SIGTERM"
+	case syscall.SIGHUP:
+		return "SIGHUP"
+	}
+	return sig.String()
+}
+
+// abandon ends a strict session early, recording why.
+func (m *model) abandon(reason string) {
+	m.abandonReason = reason
+	m.done = true
+	m.shutdown()
+}
+
+func (m *model) openQuitPrompt() {
+	m.quitting = true
+	m.quitAt = m.clock.Now()
+	m.quitInput = ""
+	m.askReason = false
+}
+
+// cooldownLeft is how long until the quit prompt accepts enter without
+// the phrase.
+func (m model) cooldownLeft() time.Duration {
+	return max(m.strict.cooldown-m.clock.Now().Sub(m.quitAt), 0)
+}
+
+// updateQuitPrompt handles keys while the strict quit prompt is open.
+func (m model) updateQuitPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.quitting = false
+		m.setNotice("back to work")
+	case tea.KeyEnter:
+		input := strings.TrimSpace(m.quitInput)
+		switch {
+		case m.askReason && input != "":
+			m.abandon(input)
+			return m, tea.Quit
+		case m.askReason:
+		case strings.EqualFold(input, m.strict.phrase) || m.cooldownLeft() == 0:
+			m.askReason = true
+			m.quitInput = ""
+		}
+	case tea.KeyBackspace:
+		if r := []rune(m.quitInput); len(r) > 0 {
+			m.quitInput = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		m.quitInput += " "
+	case tea.KeyRunes:
+		m.quitInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m model) renderQuitPrompt() string {
+	prompt := lipgloss.NewStyle().Bold(true).Foreground(colorRed)
+	dim := lipgloss.NewStyle().Foreground(colorDim)
+
+	var lines []string
+	if m.askReason {
+		lines = append(lines, prompt.Render("Why are you stopping?"))
+	} else {
+		lines = append(lines, prompt.Render(fmt.Sprintf("Type %q to quit", m.strict.phrase)))
+		if left := m.cooldownLeft(); left > 0 {
+			lines = append(lines, dim.Render("or wait "+formatDuration(left)+" and press enter"))
+		} else {
+			lines = append(lines, dim.Render("or press enter"))
+		}
+	}
+	lines = append(lines, "> "+m.quitInput+"█", dim.Render("esc to keep going"))
+	return lipgloss.JoinVertical(lipgloss.Center, lines...)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var clockLayouts = []string{"15:04", "3:04pm", "3pm", "15"}
+
+// parseUntil parses a wall-clock end time such as "14:30", "9am" or
+// "9:15pm tomorrow". Without "today" or "tomorrow", a time that has
+// already passed today means tomorrow.
+func parseUntil(spec string, now time.Time) (time.Time, error) {
+	fields := strings.Fields(strings.ToLower(spec))
+	if len(fields) == 0 || len(fields) > 2 {
+		return time.Time{}, fmt.Errorf("invalid end time %q (use e.g. 14:30, 9am, or 9am tomorrow)", spec)
+	}
+
+	day := ""
+	if len(fields) == 2 {
+		day = fields[1]
+		if day != "today" && day != "tomorrow" {
+			return time.Time{}, fmt.Errorf("invalid day %q (use today or tomorrow)", fields[1])
+		}
+	}
+
+	var clock time.Time
+	var err error
+	for _, layout := range clockLayouts {
+		if clock, err = time.Parse(layout, fields[0]); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid end time %q (use e.g. 14:30, 9am, or 9am tomorrow)", fields[0])
+	}
+
+	t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	switch {
+	case day == "tomorrow":
+		t = t.AddDate(0, 0, 1)
+	case day == "today" && !t.After(now):
+		return time.Time{}, fmt.Errorf("%s today has already passed", t.Format("15:04"))
+	case day == "" && !t.After(now):
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
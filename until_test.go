@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseUntil(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day := func(d, h, m int) time.Time { return time.Date(2026, 3, d, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"14:30", day(1, 14, 30)},
+		{"3pm", day(1, 15, 0)},
+		{"3:45PM", day(1, 15, 45)},
+		{"9am", day(2, 9, 0)}, // already passed today
+		{"10", day(2, 10, 0)}, // now is not in the future
+		{"9:15pm tomorrow", day(2, 21, 15)},
+		{"11am today", day(1, 11, 0)},
+		{"9am tomorrow", day(2, 9, 0)},
+	}
+	for _, tt := range tests {
+		got, err := parseUntil(tt.in, now)
+		if err != nil {
+			t.Errorf("parseUntil(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseUntil(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "noon", "25:00", "9am monday", "9am today", "9 am tomorrow"} {
+		if got, err := parseUntil(in, now); err == nil {
+			t.Errorf("parseUntil(%q) = %s, want an error", in, got)
+		}
+	}
+}
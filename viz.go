@@ -2,7 +2,6 @@ package main
 
 import (
 	"math"
-	"math/rand"
 	"strings"
 	"time"
 
@@ -13,10 +12,10 @@ func (m model) progressFraction() float64 {
 	if m.totalDuration == 0 {
 		return 0
 	}
-	elapsed := m.totalDuration - m.remaining
-	// Interpolate sub-second progress using wall clock
-	if !m.paused && !m.done && !m.lastTickAt.IsZero() {
-		elapsed += time.Since(m.lastTickAt)
+	// Sub-second progress comes straight from the deadline
+	elapsed := m.totalDuration - m.remainingAt(m.clock.Now())
+	if elapsed < 0 {
+		elapsed = 0
 	}
 	// Finish viz with 10% of time remaining so the completed state is visible
 	frac := float64(elapsed) / (float64(m.totalDuration) * 0.9)
@@ -26,10 +25,10 @@ func (m model) progressFraction() float64 {
 	return frac
 }
 
-// neonPulse returns the current pulse position and width for a neon sweep effect.
-func neonPulse(width float64) (pos, pulseWidth float64) {
+// neonPulse returns the pulse position and width at now for a neon sweep effect.
+func neonPulse(now time.Time, width float64) (pos, pulseWidth float64) {
 	const period = 2.0 // seconds per sweep
-	t := math.Mod(float64(time.Now().UnixMilli())/1000.0, period) / period
+	t := math.Mod(float64(now.UnixMilli())/1000.0, period) / period
 	pos = t * width
 	pulseWidth = math.Max(width*0.05, 1.0)
 	return
@@ -96,7 +95,7 @@ func (m *model) updateBarSlice() {
 	}
 
 	// If current animation is done, settle one slice forward
-	if !m.barSliceAt.IsZero() && time.Since(m.barSliceAt) >= barSliceDuration {
+	if !m.barSliceAt.IsZero() && m.clock.Now().Sub(m.barSliceAt) >= barSliceDuration {
 		m.barPrevFilled += sliceWidth
 		if m.barPrevFilled > maxWidth {
 			m.barPrevFilled = maxWidth
@@ -110,7 +109,7 @@ func (m *model) updateBarSlice() {
 		if filled > m.barPrevFilled+sliceWidth {
 			m.barPrevFilled = filled - sliceWidth
 		}
-		m.barSliceAt = time.Now()
+		m.barSliceAt = m.clock.Now()
 	}
 }
 
@@ -123,7 +122,7 @@ func (m model) renderBar() string {
 	// Slice animation: the block slides in from the right
 	var slicePos float64 = -1
 	if !m.barSliceAt.IsZero() {
-		elapsed := time.Since(m.barSliceAt)
+		elapsed := m.clock.Now().Sub(m.barSliceAt)
 		if elapsed < barSliceDuration {
 			sliceFrac := float64(elapsed) / float64(barSliceDuration)
 			target := float64(settled)
@@ -172,7 +171,7 @@ func (m *model) initDefragGrid() {
 		m.defragOriginal[i] = 1
 	}
 	// Shuffle to create chaotic layout
-	rand.Shuffle(total, func(i, j int) {
+	m.rng.Shuffle(total, func(i, j int) {
 		m.defragOriginal[i], m.defragOriginal[j] = m.defragOriginal[j], m.defragOriginal[i]
 	})
 }
@@ -264,7 +263,7 @@ func (m *model) initSortGrid() {
 	for i := range arr {
 		arr[i] = i
 	}
-	rand.Shuffle(total, func(i, j int) {
+	m.rng.Shuffle(total, func(i, j int) {
 		arr[i], arr[j] = arr[j], arr[i]
 	})
 
@@ -485,7 +484,7 @@ func (m *model) updateBinaryFade() {
 		m.binaryPrevBits = currentBits
 		m.binaryOnAt = make([]time.Time, totalBits)
 		m.binaryOffAt = make([]time.Time, totalBits)
-		now := time.Now()
+		now := m.clock.Now()
 		for i, on := range currentBits {
 			if on {
 				m.binaryOnAt[i] = now
@@ -494,7 +493,7 @@ func (m *model) updateBinaryFade() {
 		return
 	}
 
-	now := time.Now()
+	now := m.clock.Now()
 	for i := range currentBits {
 		if !m.binaryPrevBits[i] && currentBits[i] {
 			m.binaryOnAt[i] = now
@@ -526,7 +525,7 @@ func (m model) renderBinary() string {
 			if d&bitValues[r] != 0 {
 				color := baseColor
 				if bitIdx < len(m.binaryOnAt) && !m.binaryOnAt[bitIdx].IsZero() {
-					elapsed := time.Since(m.binaryOnAt[bitIdx])
+					elapsed := m.clock.Now().Sub(m.binaryOnAt[bitIdx])
 					if elapsed < binaryFlareDuration {
 						frac := float64(elapsed) / float64(binaryFlareDuration)
 						color = modifyColor(baseColor, func(c hsl) hsl {
@@ -538,7 +537,7 @@ func (m model) renderBinary() string {
 				rows[r].WriteString(lipgloss.NewStyle().Foreground(color).Render("██"))
 			} else {
 				if bitIdx < len(m.binaryOffAt) && !m.binaryOffAt[bitIdx].IsZero() {
-					elapsed := time.Since(m.binaryOffAt[bitIdx])
+					elapsed := m.clock.Now().Sub(m.binaryOffAt[bitIdx])
 					if elapsed < binaryFadeDuration {
 						fade := 1.0 - float64(elapsed)/float64(binaryFadeDuration)
 						fadeColor := modifyColor(baseColor, func(c hsl) hsl {
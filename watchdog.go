@@ -0,0 +1,425 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// A watchdog is a detached copy of lockin that outlives the terminal. It
+// idles while the session's process is alive; if that process dies
+// without ending the session (the terminal was closed, or it was
+// killed), the watchdog takes over blocking until the session's deadline.
+// The next lockin either reopens the session with lockin attach or
+// cleans up after a watchdog that died itself.
+
+// watchdogPoll is how often the watchdog checks on the session.
+const watchdogPoll = time.Second
+
+// watchdogState is the file the session and its watchdog share. The
+// session rewrites it when its deadline moves and removes it when it
+// ends normally.
+type watchdogState struct {
+	PID       int                 `json:"pid"`      // session process
+	Watchdog  int                 `json:"watchdog"` // watchdog process
+	Task      string              `json:"task,omitempty"`
+	End       time.Time           `json:"end,omitzero"`           // when blocking stops, unless paused
+	Paused    bool                `json:"paused,omitempty"`       // remaining is frozen
+	Remaining int64               `json:"remaining_sec,omitzero"` // left while paused
+	Phase     phase               `json:"phase,omitempty"`        // current pomodoro phase
+	Cycle     int                 `json:"cycle,omitempty"`        // its cycle, 1-based
+	Later     int64               `json:"later_sec,omitzero"`     // pomodoro phases after the current one
+	Settings  map[string][]string `json:"settings"`               // config keys that rebuild the blockers
+	Orphaned  bool                `json:"orphaned,omitempty"`     // the watchdog is blocking on its own
+	Error     string              `json:"error,omitempty"`        // why the watchdog couldn't block
+}
+
+func watchdogPath(pid int) string {
+	return filepath.Join(controlDir(), strconv.Itoa(pid)+".watchdog")
+}
+
+func readWatchdogState(path string) (watchdogState, error) {
+	var st watchdogState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st, err
+	}
+	err = json.Unmarshal(data, &st)
+	return st, err
+}
+
+// write replaces the state file in one step, so the other process never
+// reads half of it.
+func (st watchdogState) write(path string) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// end is when blocking should stop if the watchdog takes over at now.
+func (st watchdogState) end(now time.Time) time.Time {
+	if st.Paused {
+		return now.Add(time.Duration(st.Remaining) * time.Second)
+	}
+	return st.End
+}
+
+// phaseAt returns the pomodoro phase and cycle running at t for a session
+// ending at end, and when that phase ends.
+func (st watchdogState) phaseAt(plan pomodoroPlan, end, t time.Time) (phase, int, time.Time) {
+	ph, cycle := st.Phase, st.Cycle
+	until := end.Add(-time.Duration(st.Later) * time.Second)
+	for plan.enabled() && !t.Before(until) {
+		next, c, ok := plan.next(ph, cycle)
+		if !ok {
+			break
+		}
+		ph, cycle, until = next, c, until.Add(plan.duration(next))
+	}
+	return ph, cycle, until
+}
+
+// config rebuilds the session's config: its blocking, and for attach its
+// hooks and display.
+func (st watchdogState) config() (config, error) {
+	var cfg config
+	keys := make([]string, 0, len(st.Settings))
+	for key := range st.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := cfg.set(key, st.Settings[key]); err != nil {
+			return cfg, err
+		}
+	}
+	cfg.taskName = st.Task
+	return cfg, nil
+}
+
+// watchdogSettings lists the config keys a watchdog needs to block the
+// way cfg does, plus the hooks and display attach brings back.
+func watchdogSettings(cfg config) map[string][]string {
+	s := map[string][]string{}
+	add := func(key string, vals ...string) {
+		if len(vals) > 0 && vals[0] != "" {
+			s[key] = vals
+		}
+	}
+	add("block", cfg.blockApps...)
+	add("break_block", cfg.breakBlock...)
+	add("allow_only", cfg.allowApps...)
+	add("block_mode", cfg.blockMode)
+	add("block_grace", cfg.blockGrace.String())
+	add("block_sites", cfg.blockSites...)
+	add("hosts_file", cfg.hostsFile)
+	add("block_command", cfg.blockCommand)
+	for event, command := range cfg.hooks {
+		add("on_"+event, command)
+	}
+	add("viz", cfg.vizMode)
+	add("font", cfg.fontStyle)
+	if cfg.pomodoro.enabled() {
+		add("pomodoro", cfg.pomodoro.String())
+	}
+	if cfg.strict.enabled() {
+		add("strict", "true")
+		add("strict_phrase", cfg.strict.phrase)
+		add("strict_cooldown", cfg.strict.cooldown.String())
+		add("strict_pauses", strconv.Itoa(cfg.strict.pauses))
+		add("strict_pause", cfg.strict.pauseLimit.String())
+	}
+	return s
+}
+
+// watchdog is the session's handle on its watchdog process.
+type watchdog struct {
+	path  string
+	state watchdogState
+}
+
+// startWatchdog writes the state file and starts a detached watchdog for
+// the session ending at end, later of which are the pomodoro phases after
+// the current one.
+func startWatchdog(cfg config, end time.Time, later time.Duration) (*watchdog, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureControlDir(); err != nil {
+		return nil, err
+	}
+	w := &watchdog{
+		path: watchdogPath(os.Getpid()),
+		state: watchdogState{
+			PID:      os.Getpid(),
+			Task:     cfg.taskName,
+			End:      end.Round(time.Second),
+			Phase:    cfg.phase,
+			Cycle:    max(cfg.cycle, 1),
+			Later:    int64(later.Round(time.Second) / time.Second),
+			Settings: watchdogSettings(cfg),
+		},
+	}
+	if err := w.state.write(w.path); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(exe, "_watchdog", w.path)
+	// A session of its own, so closing the terminal doesn't hang it up
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		os.Remove(w.path)
+		return nil, err
+	}
+	w.state.Watchdog = cmd.Process.Pid
+	cmd.Process.Release()
+	if err := w.state.write(w.path); err != nil {
+		w.release()
+		return nil, err
+	}
+	return w, nil
+}
+
+// update records where the session stands: in phase ph of cycle, with
+// left to go in it and later in the phases after it, either paused or
+// running. It only writes when that changes.
+func (w *watchdog) update(paused bool, left, later time.Duration, ph phase, cycle int, now time.Time) {
+	next := w.state
+	next.Paused = paused
+	next.Phase, next.Cycle = ph, cycle
+	next.Later = int64(later.Round(time.Second) / time.Second)
+	if paused {
+		next.Remaining = int64(ceilSecond(left+later) / time.Second)
+	} else {
+		next.Remaining = 0
+		next.End = now.Add(left + later).Round(time.Second)
+	}
+	drift := next.End.Sub(w.state.End)
+	if next.Paused == w.state.Paused && next.Remaining == w.state.Remaining && drift.Abs() <= time.Second &&
+		next.Phase == w.state.Phase && next.Cycle == w.state.Cycle && next.Later == w.state.Later {
+		return
+	}
+	if next.write(w.path) == nil {
+		w.state = next
+	}
+}
+
+// release ends the session normally: the watchdog sees its state file
+// gone and exits without blocking.
+func (w *watchdog) release() {
+	os.Remove(w.path)
+}
+
+// runWatchdog is the detached process started by startWatchdog.
+func runWatchdog(path string) {
+	signal.Ignore(syscall.SIGHUP, syscall.SIGINT)
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, syscall.SIGTERM)
+
+	ticker := time.NewTicker(watchdogPoll)
+	defer ticker.Stop()
+	var st watchdogState
+	for {
+		select {
+		case <-term:
+			return
+		case <-ticker.C:
+		}
+		var err error
+		if st, err = readWatchdogState(path); err != nil {
+			return
+		}
+		if !alive(st.PID) {
+			break
+		}
+	}
+
+	// The session died without ending, so block until its deadline
+	end := st.end(time.Now())
+	cfg, err := st.config()
+	if err != nil || !time.Now().Before(end) {
+		os.Remove(path)
+		return
+	}
+	// Nobody is watching for a warning, so close apps straight away
+	cfg.blockWarning = 0
+	blockers := newBlockers(cfg)
+	st.Orphaned = true
+	st.Paused, st.Remaining, st.End = false, 0, end
+
+	// Follow the session's pomodoro schedule, switching to break rules
+	// or pausing for breaks like the session would
+	onBreak := func() bool {
+		ph, _, _ := st.phaseAt(cfg.pomodoro, end, time.Now())
+		return ph != phaseWork
+	}
+	wasBreak := onBreak()
+	if err := startBlockers(blockers, wasBreak); err != nil {
+		st.Error = err.Error()
+		st.write(path)
+		return
+	}
+	st.write(path)
+
+	deadline := time.NewTimer(time.Until(end))
+	defer deadline.Stop()
+loop:
+	for {
+		select {
+		case <-term:
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-ticker.C:
+			// lockin attach or a cleanup took the session back
+			if _, err := os.Stat(path); err != nil {
+				break loop
+			}
+			if b := onBreak(); b != wasBreak {
+				syncBlockers(blockers, false, wasBreak, false, b)
+				wasBreak = b
+			}
+		}
+	}
+	stopBlockers(blockers)
+	os.Remove(path)
+}
+
+// orphanedSessions returns the sessions a watchdog is blocking for, and
+// cleans up after sessions whose watchdog died: their state file is
+// removed and anything their blockers left behind is undone.
+func orphanedSessions() []watchdogState {
+	if checkControlDir(controlDir()) != nil {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(controlDir(), "*.watchdog"))
+	var orphans []watchdogState
+	for _, path := range paths {
+		// Only act on state files this user wrote
+		if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() || !ownedByUser(info) {
+			continue
+		}
+		st, err := readWatchdogState(path)
+		if err != nil {
+			continue
+		}
+		switch {
+		case alive(st.Watchdog) && st.Orphaned && st.Error == "":
+			orphans = append(orphans, st)
+		case alive(st.Watchdog), alive(st.PID):
+			// Still watching, or its session is still running
+		default:
+			if st.Orphaned {
+				cleanUpBlocking(st)
+			}
+			os.Remove(path)
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].End.Before(orphans[j].End) })
+	return orphans
+}
+
+// alive is processAlive for a pid that may not have been recorded yet.
+func alive(pid int) bool { return pid > 0 && processAlive(pid) }
+
+// cleanUpBlocking undoes what a dead watchdog's blockers changed outside
+// its own process: the hosts file section and the custom command.
+func cleanUpBlocking(st watchdogState) {
+	cfg, err := st.config()
+	if err != nil {
+		return
+	}
+	if len(cfg.blockSites) > 0 {
+		newHostsBlocker(cfg.hostsFile, nil).Start()
+	}
+	if cfg.blockCommand != "" {
+		newCommandBlocker(cfg.blockCommand).run("stop")
+	}
+}
+
+// warnOrphans points at sessions still blocked by a watchdog.
+func warnOrphans(orphans []watchdogState) {
+	for _, st := range orphans {
+		name := "an earlier session"
+		if st.Task != "" {
+			name = fmt.Sprintf("%q", st.Task)
+		}
+		fmt.Fprintf(os.Stderr, "lockin: %s is still blocking until %s (lockin attach to reopen it)\n",
+			name, st.End.Local().Format("15:04"))
+	}
+}
+
+// runAttach reopens the newest session a watchdog is blocking for, with
+// the time it has left.
+func runAttach(args []string) {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: lockin attach")
+		if args[0] == "-h" || args[0] == "--help" {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+	orphans := orphanedSessions()
+	if len(orphans) == 0 {
+		fmt.Fprintln(os.Stderr, "lockin: no session to attach to")
+		os.Exit(1)
+	}
+	st := orphans[len(orphans)-1]
+	cfg, err := st.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.resolve()
+	end := st.End
+	if cfg.pomodoro.enabled() {
+		// Pick the plan up in the phase the watchdog has reached
+		var cycle int
+		cfg.phase, cycle, end = st.phaseAt(cfg.pomodoro, st.End, time.Now())
+		cfg.cycle = max(cycle, 1)
+	}
+	cfg.duration = time.Until(end).Round(time.Second)
+	if cfg.duration <= 0 {
+		fmt.Fprintln(os.Stderr, "lockin: no session to attach to")
+		os.Exit(1)
+	}
+	cfg.watchdog = true
+	// The session started long ago; only its later hooks still apply
+	delete(cfg.hooks, "start")
+
+	// Take blocking back from the watchdog before this session starts it
+	if err := takeOver(st); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	runSession(cfg)
+}
+
+// takeOver stops st's watchdog and waits for it to undo its blocking.
+func takeOver(st watchdogState) error {
+	if err := syscall.Kill(st.Watchdog, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("could not stop watchdog %d: %v", st.Watchdog, err)
+	}
+	for range 50 {
+		if !alive(st.Watchdog) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("watchdog %d did not stop", st.Watchdog)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdogSettingsRoundTrip(t *testing.T) {
+	cfg := config{
+		blockApps:  []string{"Slack", "glob:steam*"},
+		breakBlock: []string{"Steam"},
+		blockMode:  "freeze",
+		blockSites: []string{"reddit.com"},
+		hooks:      map[string]string{"complete": "notify-send done", "pause": "playerctl pause"},
+		vizMode:    "defrag",
+		fontStyle:  "dot",
+	}
+	st := watchdogState{Task: "write the tests", Settings: watchdogSettings(cfg)}
+	got, err := st.config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.taskName != "write the tests" || got.blockMode != "freeze" || len(got.blockApps) != 2 ||
+		len(got.breakBlock) != 1 || len(got.blockSites) != 1 {
+		t.Errorf("blocking didn't survive: %+v", got)
+	}
+	if got.hooks["complete"] != "notify-send done" || got.hooks["pause"] != "playerctl pause" || len(got.hooks) != 2 {
+		t.Errorf("hooks = %v, want the session's", got.hooks)
+	}
+	if got.vizMode != "defrag" || got.fontStyle != "dot" {
+		t.Errorf("viz and font = %q %q, want defrag dot", got.vizMode, got.fontStyle)
+	}
+}
+
+func TestPhaseAt(t *testing.T) {
+	plan, err := parsePomodoro("25m/5m/15m x2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return t0.Add(time.Duration(min) * time.Minute) }
+
+	// Started at t0: work, short break, work, long break, ending at 70m
+	running := watchdogState{Phase: phaseWork, Cycle: 1, Later: 45 * 60, End: at(70)}
+	// Paused at t0 with 2m of the first short break left
+	paused := watchdogState{Phase: phaseShortBreak, Cycle: 1, Later: 40 * 60, Paused: true, Remaining: 42 * 60}
+
+	tests := []struct {
+		name  string
+		st    watchdogState
+		t     time.Time
+		phase phase
+		cycle int
+		until time.Time
+	}{
+		{"mid work", running, at(10), phaseWork, 1, at(25)},
+		{"short break", running, at(27), phaseShortBreak, 1, at(30)},
+		{"second work", running, at(32), phaseWork, 2, at(55)},
+		{"long break", running, at(60), phaseLongBreak, 2, at(70)},
+		{"past the last phase", running, at(80), phaseLongBreak, 2, at(70)},
+		{"paused", paused, at(0), phaseShortBreak, 1, at(2)},
+		{"taken over while paused", paused, at(10), phaseWork, 2, at(27)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The watchdog takes over at t0, freezing a paused session's end
+			ph, cycle, until := tt.st.phaseAt(plan, tt.st.end(t0), tt.t)
+			if ph != tt.phase || cycle != tt.cycle || !until.Equal(tt.until) {
+				t.Errorf("phaseAt = %s of cycle %d until %s, want %s of cycle %d until %s",
+					ph, cycle, until.Format(time.Kitchen), tt.phase, tt.cycle, tt.until.Format(time.Kitchen))
+			}
+		})
+	}
+}